@@ -0,0 +1,92 @@
+package bitset
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// Atomic is a fixed-size set of integers from 0 to Len()-1 whose elements
+// can be added, removed and tested by multiple goroutines without locking.
+// The number of words is established at construction and never changes,
+// since atomic operations can't tolerate the words being reallocated.
+// Elements outside of [0, Len()) are ignored by all methods.
+type Atomic struct {
+	words []uint64
+}
+
+// NewAtomic creates a new empty atomic set that can hold the integers from 0 to n-1.
+func NewAtomic(n int) *Atomic {
+	if n < 1 {
+		return &Atomic{}
+	}
+	return &Atomic{words: make([]uint64, ((n-1)>>shift)+1)}
+}
+
+// Len returns the number of integers the set can hold.
+func (a *Atomic) Len() int {
+	return len(a.words) << shift
+}
+
+// Add atomically adds n to the set and tells if it was newly added,
+// i.e. it was not present before.
+func (a *Atomic) Add(n int) bool {
+	if n < 0 || n>>shift >= len(a.words) {
+		return false
+	}
+	mask := uint64(1) << uint(n&div64rem)
+	return atomic.OrUint64(&a.words[n>>shift], mask)&mask == 0
+}
+
+// Contains atomically tells if n is in the set.
+func (a *Atomic) Contains(n int) bool {
+	if n < 0 || n>>shift >= len(a.words) {
+		return false
+	}
+	return atomic.LoadUint64(&a.words[n>>shift])&(1<<uint(n&div64rem)) != 0
+}
+
+// TestAndClear atomically removes n from the set and tells if it was present.
+func (a *Atomic) TestAndClear(n int) bool {
+	if n < 0 || n>>shift >= len(a.words) {
+		return false
+	}
+	mask := uint64(1) << uint(n&div64rem)
+	return atomic.AndUint64(&a.words[n>>shift], ^mask)&mask != 0
+}
+
+// Size returns the number of elements in the set. The words are loaded
+// one by one, so concurrent changes are only eventually reflected.
+func (a *Atomic) Size() int {
+	size := 0
+	for i := range a.words {
+		size += bits.OnesCount64(atomic.LoadUint64(&a.words[i]))
+	}
+	return size
+}
+
+// Visit calls the do function for each element of the set in numerical order
+// with the same abort semantics as BitSet.Visit. The words are loaded one by one,
+// so concurrent changes are only eventually reflected.
+func (a *Atomic) Visit(do func(n int) bool) (aborted bool) {
+	for i := range a.words {
+		w := atomic.LoadUint64(&a.words[i])
+		for w != 0 {
+			if do((i << shift) + bits.TrailingZeros64(w)) {
+				return true
+			}
+			w &= w - 1 // clear the lowest set bit
+		}
+	}
+	return false
+}
+
+// Snapshot returns a copy of the set as a BitSet. The words are loaded
+// one by one, so concurrent changes are only eventually reflected.
+func (a *Atomic) Snapshot() BitSet {
+	s := make(BitSet, len(a.words))
+	for i := range a.words {
+		s[i] = atomic.LoadUint64(&a.words[i])
+	}
+	s.trim()
+	return s
+}
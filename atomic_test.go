@@ -0,0 +1,104 @@
+package bitset
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtomic(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		len  int
+	}{
+		{"neg", -1, 0},
+		{"zero", 0, 0},
+		{"one", 1, 64},
+		{"word", 64, 64},
+		{"word and one", 65, 128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.len, NewAtomic(tt.n).Len())
+		})
+	}
+
+	a := NewAtomic(200)
+	require.True(t, a.Add(1))
+	require.False(t, a.Add(1))
+	require.True(t, a.Add(64))
+	require.False(t, a.Add(-1))
+	require.False(t, a.Add(256))
+	require.True(t, a.Contains(1))
+	require.True(t, a.Contains(64))
+	require.False(t, a.Contains(2))
+	require.False(t, a.Contains(-1))
+	require.False(t, a.Contains(1000))
+	require.Equal(t, 2, a.Size())
+
+	require.True(t, a.TestAndClear(64))
+	require.False(t, a.TestAndClear(64))
+	require.False(t, a.TestAndClear(-1))
+	require.False(t, a.TestAndClear(1000))
+	require.Equal(t, "{1}", a.Snapshot().String())
+
+	a.Add(3)
+	a.Add(190)
+	var visited []int
+	a.Visit(func(n int) bool {
+		visited = append(visited, n)
+		return false
+	})
+	require.Equal(t, []int{1, 3, 190}, visited)
+	require.Len(t, NewAtomic(1000).Snapshot(), 0)
+}
+
+func TestAtomic_Concurrent(t *testing.T) {
+	const goroutines, span = 32, 1000
+	a := NewAtomic(goroutines * span)
+	reference := New()
+	var added atomic.Int64
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		// even goroutines add disjoint ranges, odd ones overlap with their neighbors
+		start := g * span
+		if g%2 == 1 {
+			start -= span / 2
+		}
+		reference.AddRange(start, start+span)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < start+span; i++ {
+				if a.Add(i) {
+					added.Add(1)
+				}
+				a.Contains(i)
+			}
+		}()
+	}
+	wg.Wait()
+	require.True(t, a.Snapshot().Equal(reference))
+	require.Equal(t, int64(reference.Size()), added.Load(), "every element is newly added exactly once")
+	require.Equal(t, reference.Size(), a.Size())
+
+	var cleared atomic.Int64
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range goroutines * span {
+				if a.TestAndClear(i) {
+					cleared.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(reference.Size()), cleared.Load(), "every element is cleared exactly once")
+	require.Zero(t, a.Size())
+}
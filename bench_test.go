@@ -2,8 +2,10 @@
 package bitset
 
 import (
+	"math/rand/v2"
 	"strconv"
 	"testing"
+	"unsafe"
 )
 
 // setupBenchmarkSets creates BitSets of various sizes for benchmarking
@@ -40,6 +42,25 @@ func BenchmarkNew(b *testing.B) {
 	}
 }
 
+func BenchmarkNewFromSorted(b *testing.B) {
+	nums := make([]int, 1_000_000)
+	for i := range nums {
+		nums[i] = i * 3
+	}
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			New(nums...)
+		}
+	})
+	b.Run("NewFromSorted", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			NewFromSorted(nums)
+		}
+	})
+}
+
 func BenchmarkBitSet_Add(b *testing.B) {
 	scenarios := []struct {
 		name string
@@ -87,6 +108,13 @@ func BenchmarkBitSet_Contains(b *testing.B) {
 			large.Contains(5001)
 		}
 	})
+
+	b.Run("out of range", func(b *testing.B) {
+		for b.Loop() {
+			large.Contains(-1)
+			large.Contains(1 << 20)
+		}
+	})
 }
 
 func BenchmarkBitSet_AddRange(b *testing.B) {
@@ -421,3 +449,587 @@ func BenchmarkBitSet_Visit(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkBitSet_Intersects(b *testing.B) {
+	large1, large2 := New(), New()
+	for i := range 10000 {
+		if i%2 == 0 {
+			large1.Add(i)
+		}
+		if i%3 == 0 {
+			large2.Add(i)
+		}
+	}
+
+	b.Run("intersects", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = large1.Intersects(large2)
+		}
+	})
+
+	b.Run("and empty", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = !And(large1, large2).Empty()
+		}
+	})
+}
+
+func BenchmarkBitSet_All(b *testing.B) {
+	small, large := setupBenchmarkSets()
+	dummy := 0 // Used to prevent compiler optimizations
+
+	b.Run("small set", func(b *testing.B) {
+		for b.Loop() {
+			for n := range small.All() {
+				dummy += n
+			}
+		}
+	})
+
+	b.Run("large set", func(b *testing.B) {
+		for b.Loop() {
+			for n := range large.All() {
+				dummy += n
+			}
+		}
+	})
+}
+
+func BenchmarkBitSet_Backward(b *testing.B) {
+	small, large := setupBenchmarkSets()
+	dummy := 0 // Used to prevent compiler optimizations
+
+	b.Run("small set", func(b *testing.B) {
+		for b.Loop() {
+			for n := range small.Backward() {
+				dummy += n
+			}
+		}
+	})
+
+	b.Run("large set", func(b *testing.B) {
+		for b.Loop() {
+			for n := range large.Backward() {
+				dummy += n
+			}
+		}
+	})
+}
+
+func BenchmarkAndTo(b *testing.B) {
+	large1, large2 := New(), New()
+	for i := range 10000 {
+		if i%2 == 0 {
+			large1.Add(i)
+		}
+		if i%3 == 0 {
+			large2.Add(i)
+		}
+	}
+	dst := make(BitSet, 0, len(large1))
+
+	b.Run("and", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = And(large1, large2)
+		}
+	})
+
+	b.Run("and to", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			AndTo(&dst, large1, large2)
+		}
+	})
+
+	b.Run("or to", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			OrTo(&dst, large1, large2)
+		}
+	})
+
+	b.Run("xor to", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			XorTo(&dst, large1, large2)
+		}
+	})
+
+	b.Run("and not to", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			AndNotTo(&dst, large1, large2)
+		}
+	})
+}
+
+func BenchmarkUnion(b *testing.B) {
+	sets := make([]BitSet, 50)
+	for i := range sets {
+		sets[i] = New()
+		for j := i; j < 10000; j += 50 {
+			sets[i].Add(j)
+		}
+	}
+
+	b.Run("union", func(b *testing.B) {
+		for b.Loop() {
+			_ = Union(sets...)
+		}
+	})
+
+	b.Run("pairwise", func(b *testing.B) {
+		for b.Loop() {
+			s := sets[0]
+			for _, set := range sets[1:] {
+				s = Or(s, set)
+			}
+		}
+	})
+}
+
+func BenchmarkIntersection(b *testing.B) {
+	sets := make([]BitSet, 50)
+	for i := range sets {
+		sets[i] = New()
+		sets[i].AddRange(0, 10000-i)
+	}
+
+	b.Run("intersection", func(b *testing.B) {
+		for b.Loop() {
+			_ = Intersection(sets...)
+		}
+	})
+
+	b.Run("pairwise", func(b *testing.B) {
+		for b.Loop() {
+			s := sets[0]
+			for _, set := range sets[1:] {
+				s = And(s, set)
+			}
+		}
+	})
+}
+
+func BenchmarkBitSet_OrAccumulate(b *testing.B) {
+	sets := make([]BitSet, 64)
+	for i := range sets {
+		sets[i] = New(i * 1000)
+	}
+	acc := New()
+
+	b.Run("accumulator", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			acc.Reset()
+			for _, s := range sets {
+				acc.Or(s)
+			}
+		}
+	})
+}
+
+func BenchmarkBitSet_OrAll(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	sets := make([]BitSet, 10000)
+	for i := range sets {
+		sets[i] = New(r.IntN(100000), r.IntN(100000), r.IntN(100000))
+	}
+	acc := New()
+
+	b.Run("Or loop", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			acc.Reset()
+			for _, s := range sets {
+				acc.Or(s)
+			}
+		}
+	})
+	b.Run("OrAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			acc.Reset()
+			acc.OrAll(sets...)
+		}
+	})
+}
+
+func BenchmarkFromRange(b *testing.B) {
+	b.Run("from range", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = FromRange(10, 100000)
+		}
+	})
+
+	b.Run("new add range", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			bs := New()
+			bs.AddRange(10, 100000)
+		}
+	})
+}
+
+func BenchmarkBitSet_Grow(b *testing.B) {
+	b.Run("without grow", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			bs := New()
+			for i := range 100_000 {
+				bs.Add(i)
+			}
+		}
+	})
+
+	b.Run("with grow", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			bs := New()
+			bs.Grow(100_000)
+			for i := range 100_000 {
+				bs.Add(i)
+			}
+		}
+	})
+}
+
+func BenchmarkRuns_AddRange(b *testing.B) {
+	b.Run("bitset", func(b *testing.B) {
+		b.ReportAllocs()
+		var bs BitSet
+		for b.Loop() {
+			bs = New()
+			bs.AddRange(0, 10_000_000)
+		}
+		b.ReportMetric(float64(cap(bs)*8), "bytes/set")
+	})
+
+	b.Run("runs", func(b *testing.B) {
+		b.ReportAllocs()
+		var rs Runs
+		for b.Loop() {
+			rs = nil
+			rs.AddRange(0, 10_000_000)
+		}
+		b.ReportMetric(float64(cap(rs))*float64(unsafe.Sizeof(Run{})), "bytes/set")
+	})
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	random := make([]int, 1_000_000)
+	for i := range random {
+		random[i] = r.IntN(10_000_000)
+	}
+	ascending := make([]int, 1_000_000)
+	for i := range ascending {
+		ascending[i] = i * 10
+	}
+
+	for _, input := range []struct {
+		name  string
+		elems []int
+	}{{"random", random}, {"ascending", ascending}} {
+		b.Run(input.name+" add", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				var bs BitSet
+				for _, e := range input.elems {
+					bs.Add(e)
+				}
+			}
+		})
+
+		b.Run(input.name+" builder", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				var bld Builder
+				for _, e := range input.elems {
+					bld.Add(e)
+				}
+				_ = bld.Build()
+			}
+		})
+
+		b.Run(input.name+" builder slice", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				var bld Builder
+				bld.AddSlice(input.elems)
+				_ = bld.Build()
+			}
+		})
+	}
+}
+
+func BenchmarkBitSet_AppendString(b *testing.B) {
+	bs := New()
+	for i := range 300 {
+		bs.AddRange(i*1000, i*1000+i%5+1)
+	}
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = bs.String()
+		}
+	})
+
+	b.Run("append string", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 4096)
+		for b.Loop() {
+			buf = bs.AppendString(buf[:0])
+		}
+	})
+}
+
+func BenchmarkIntersectionSize(b *testing.B) {
+	_, large := setupBenchmarkSets()
+	other := large.Copy()
+	other.ShiftLeft(2)
+
+	b.Run("and size", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = And(large, other).Size()
+		}
+	})
+
+	b.Run("intersection size", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = IntersectionSize(large, other)
+		}
+	})
+}
+
+func BenchmarkFromBools(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	mask := make([]bool, 100_000)
+	for i := range mask {
+		mask[i] = r.IntN(2) == 0
+	}
+
+	b.Run("from bools", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = FromBools(mask)
+		}
+	})
+
+	b.Run("add", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			bs := New()
+			for i, v := range mask {
+				if v {
+					bs.Add(i)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkBitSet_MarshalCompressed(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	sparse := New()
+	for range 300 {
+		sparse.Add(r.IntN(10_000_000))
+	}
+	_, dense := setupBenchmarkSets()
+
+	for _, set := range []struct {
+		name string
+		bs   BitSet
+	}{{"sparse", sparse}, {"dense", dense}} {
+		b.Run(set.name+" compressed", func(b *testing.B) {
+			b.ReportAllocs()
+			var data []byte
+			for b.Loop() {
+				data = set.bs.MarshalCompressed()
+				_, _ = UnmarshalCompressed(data)
+			}
+			b.ReportMetric(float64(len(data)), "bytes/set")
+		})
+
+		b.Run(set.name+" bytes", func(b *testing.B) {
+			b.ReportAllocs()
+			var data []byte
+			for b.Loop() {
+				data = set.bs.Bytes()
+				_ = FromBytes(data)
+			}
+			b.ReportMetric(float64(len(data)), "bytes/set")
+		})
+	}
+}
+
+func BenchmarkCounted_Size(b *testing.B) {
+	const words = 1 << 20
+	bs := FromRange(0, words*bpw)
+	c := CountedFrom(bs)
+
+	b.Run("BitSet", func(b *testing.B) {
+		for i := 0; b.Loop(); i++ {
+			bs.Delete(i % (words * bpw))
+			_ = bs.Size()
+		}
+	})
+	b.Run("Counted", func(b *testing.B) {
+		for i := 0; b.Loop(); i++ {
+			c.Delete(i % (words * bpw))
+			_ = c.Size()
+		}
+	})
+}
+
+func BenchmarkKernels(b *testing.B) {
+	binary := []struct {
+		name          string
+		fast, generic func(dst, src []uint64)
+	}{
+		{"and", andWords, andWordsGeneric},
+		{"or", orWords, orWordsGeneric},
+		{"xor", xorWords, xorWordsGeneric},
+		{"andNot", andNotWords, andNotWordsGeneric},
+	}
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, words := range []int{1 << 9, 1 << 12, 1 << 20} {
+		dst, src := make([]uint64, words), make([]uint64, words)
+		for i := range src {
+			dst[i], src[i] = r.Uint64(), r.Uint64()
+		}
+		size := strconv.Itoa(words) + " words/"
+		for _, op := range binary {
+			b.Run(size+op.name+"/generic", func(b *testing.B) {
+				b.SetBytes(int64(words) * 8)
+				for b.Loop() {
+					op.generic(dst, src)
+				}
+			})
+			b.Run(size+op.name+"/fast", func(b *testing.B) {
+				b.SetBytes(int64(words) * 8)
+				for b.Loop() {
+					op.fast(dst, src)
+				}
+			})
+		}
+		b.Run(size+"popcnt/generic", func(b *testing.B) {
+			b.SetBytes(int64(words) * 8)
+			for b.Loop() {
+				popcntWordsGeneric(src)
+			}
+		})
+		b.Run(size+"popcnt/fast", func(b *testing.B) {
+			b.SetBytes(int64(words) * 8)
+			for b.Loop() {
+				popcntWords(src)
+			}
+		})
+	}
+}
+
+func BenchmarkPool(b *testing.B) {
+	_, large := setupBenchmarkSets()
+	other := New()
+	for i := range 10000 {
+		if i%3 == 0 {
+			other.Add(i)
+		}
+	}
+
+	b.Run("And", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = And(large, other)
+		}
+	})
+	b.Run("Get AndTo Put", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			s := Get()
+			AndTo(s, large, other)
+			Put(s)
+		}
+	})
+}
+
+func BenchmarkCounted_PopMin(b *testing.B) {
+	const n = 1 << 22
+	b.Run("BitSet", func(b *testing.B) {
+		bs := FromRange(0, n)
+		for b.Loop() {
+			if bs.PopMin() < 0 {
+				bs.AddRange(0, n)
+			}
+		}
+	})
+	b.Run("Counted", func(b *testing.B) {
+		c := NewCounted()
+		c.AddRange(0, n)
+		for b.Loop() {
+			if c.PopMin() < 0 {
+				c.AddRange(0, n)
+			}
+		}
+	})
+}
+
+func BenchmarkBitSet_DeleteInterior(b *testing.B) {
+	const n = 1 << 20
+	bs := FromRange(0, 64*n)
+	for i := 0; b.Loop(); i++ {
+		e := (i * 64) % (64 * (n - 1))
+		bs.Delete(e)
+		bs.Add(e)
+	}
+}
+
+func BenchmarkSmall_New(b *testing.B) {
+	const sets = 10_000_000
+	b.Run("BitSet", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for i := range sets {
+				bs := New(i&31, 63)
+				_ = bs.Contains(i & 63)
+			}
+		}
+	})
+	b.Run("Small", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for i := range sets {
+				s := NewSmall(i&31, 63)
+				_ = s.Contains(i & 63)
+			}
+		}
+	})
+}
+
+func BenchmarkBitSet_OrChanged(b *testing.B) {
+	const words = 1 << 10
+	bs := FromRange(0, words*bpw/2)
+	other := FromRange(0, words*bpw)
+
+	b.Run("OrEqual", func(b *testing.B) {
+		s := bs.Copy()
+		for b.Loop() {
+			before := s.Copy()
+			s.Or(other)
+			_ = s.Equal(before)
+		}
+	})
+	b.Run("OrChanged", func(b *testing.B) {
+		s := bs.Copy()
+		for b.Loop() {
+			_ = s.OrChanged(other)
+		}
+	})
+}
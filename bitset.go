@@ -2,9 +2,11 @@ package bitset
 
 import (
 	"fmt"
+	"iter"
 	"math"
 	"math/bits"
-	"strings"
+	"strconv"
+	"unsafe"
 )
 
 const (
@@ -14,6 +16,32 @@ const (
 	div64rem        = 63         // remainder of division by 64 when n&div64rem is used
 )
 
+// MaxElement is the largest element a set can hold. Its words would take
+// 256 TiB, the most the Go runtime allows for a single allocation on 64-bit
+// platforms. Adding a greater element panics; use AddCapped or AddRangeCapped
+// to get an error instead.
+const MaxElement = min(math.MaxInt, 1<<51-1)
+
+// maxElement is MaxElement, lowered in tests to check the limit
+// without huge allocations.
+var maxElement = MaxElement
+
+// checkElement returns an error if n is greater than maxAllowed or maxElement.
+func checkElement(n, maxAllowed int) error {
+	maxAllowed = min(maxAllowed, maxElement)
+	if n > maxAllowed {
+		return fmt.Errorf("bitset: element %d exceeds the maximum of %d", n, maxAllowed)
+	}
+	return nil
+}
+
+// mustFit panics if n is greater than maxElement.
+func mustFit(n int) {
+	if err := checkElement(n, maxElement); err != nil {
+		panic(err)
+	}
+}
+
 // BitSet is a set of non-negative integers represented as a slice of uint64 words,
 // where each bit i in word w corresponds to the integer 64*n + i.
 // The words are kept in ascending order, and the set is trimmed
@@ -21,22 +49,29 @@ const (
 type BitSet []uint64
 
 // New creates a new set with the given non-negative elements.
-// If all n are negative, an empty set is created. The elements are stored in
+// If all n are negative, an empty set is created. New panics if an element
+// is greater than MaxElement. The elements are stored in
 // ascending order. The zero value of BitSet is an empty set.
 func New(n ...int) BitSet {
 	if len(n) == 0 {
 		return BitSet{}
 	}
-	maxElem := -1
+	maxElem, minElem := -1, 0
 	for _, e := range n {
-		if e > maxElem {
-			maxElem = e
-		}
+		maxElem = max(maxElem, e)
+		minElem = min(minElem, e)
 	}
 	if maxElem < 0 {
 		return BitSet{}
 	}
+	mustFit(maxElem)
 	s := make(BitSet, (maxElem>>shift)+1)
+	if minElem == 0 { // no negative elements to skip
+		for _, e := range n {
+			s[e>>shift] |= 1 << uint(e&div64rem)
+		}
+		return s
+	}
 	for _, e := range n {
 		if e >= 0 {
 			s[e>>shift] |= 1 << uint(e&div64rem)
@@ -45,30 +80,190 @@ func New(n ...int) BitSet {
 	return s
 }
 
-// Reset resets the set without reallocation.
-func (bs *BitSet) Reset() {
-	for i := range *bs {
-		(*bs)[i] = 0
+// NewFromSorted creates a new set with the non-negative elements of n,
+// which are expected in ascending order. It allocates once based on the
+// last element and skips the max-finding pass of New. Negative elements
+// and duplicates are skipped. Out-of-order input is tolerated: elements
+// not greater than the last one are still added, and if a greater or
+// a non-leading negative element is found NewFromSorted falls back to New. It panics if an element
+// is greater than MaxElement.
+func NewFromSorted(n []int) BitSet {
+	if len(n) == 0 || n[len(n)-1] < 0 {
+		return New(n...)
+	}
+	last := n[len(n)-1]
+	mustFit(last)
+	s := make(BitSet, (last>>shift)+1)
+	for len(n) > 0 && n[0] < 0 {
+		n = n[1:]
+	}
+	i, w := 0, uint64(0) // current word index and its accumulated bits
+	for _, e := range n {
+		if uint(e) > uint(last) { // out of order, including negatives
+			return New(n...)
+		}
+		if j := e >> shift; j != i {
+			s[i] |= w
+			i, w = j, 0
+		}
+		w |= 1 << uint(e&div64rem)
+	}
+	s[i] |= w
+	return s
+}
+
+// NewCapped creates a new set with the given non-negative elements like New,
+// but returns an error instead of allocating if any element exceeds maxAllowed.
+// It guards against huge allocations caused by untrusted input.
+func NewCapped(maxAllowed int, n ...int) (BitSet, error) {
+	for _, e := range n {
+		if err := checkElement(e, maxAllowed); err != nil {
+			return nil, err
+		}
+	}
+	return New(n...), nil
+}
+
+// FromRange creates a new set with all integers from m to n-1
+// (an empty set if m>=n). It panics if n-1 is greater than MaxElement.
+func FromRange(m, n int) BitSet {
+	if n < 1 || m >= n {
+		return BitSet{}
 	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	mustFit(n)
+	low, high := m>>shift, n>>shift
+	s := make(BitSet, high+1)
+	if low == high {
+		s[low] = bitMask(m&div64rem, n&div64rem)
+		return s
+	}
+	s[low] = bitMask(m&div64rem, bpw-1)
+	for i := low + 1; i < high; i++ {
+		s[i] = maxw
+	}
+	s[high] = bitMask(0, n&div64rem)
+	return s
+}
+
+// Universe creates a new set with all integers from 0 to n-1.
+func Universe(n int) BitSet {
+	return FromRange(0, n)
+}
+
+// FromWords creates a new set from a copy of the words w, where bit i of w[j]
+// corresponds to the element 64*j + i. Trailing zero words are dropped,
+// so the set satisfies the invariants checked by Validate.
+func FromWords(w []uint64) BitSet {
+	return BitSet(w[:BitSet(w).trimmedLen()]).Copy()
+}
+
+// FromBools creates a new set with the elements i for which b[i] is true.
+func FromBools(b []bool) BitSet {
+	last := len(b) - 1
+	for last >= 0 && !b[last] {
+		last--
+	}
+	if last < 0 {
+		return BitSet{}
+	}
+	s := make(BitSet, (last>>shift)+1)
+	for i := range s {
+		chunk := b[i<<shift : min((i+1)<<shift, last+1)]
+		var w uint64
+		for j, v := range chunk {
+			if v {
+				w |= 1 << uint(j)
+			}
+		}
+		s[i] = w
+	}
+	return s
+}
+
+// Reset resets the set without reallocation. The capacity is kept
+// for reuse; see ResetFree to release it.
+func (bs *BitSet) Reset() {
 	*bs = (*bs)[:0]
 }
 
+// ResetFree resets the set and drops its storage, so that it can be
+// garbage collected. Later additions allocate anew.
+func (bs *BitSet) ResetFree() {
+	*bs = BitSet{}
+}
+
 // Contains tells if n is in the set.
 func (bs BitSet) Contains(n int) bool {
-	if n < 0 {
+	// A negative n becomes a huge index, so a single unsigned comparison
+	// checks both bounds and lets the compiler drop the bounds check.
+	if i := uint(n) >> shift; i < uint(len(bs)) {
+		return bs[i]&(1<<uint(n&div64rem)) != 0
+	}
+	return false
+}
+
+// ContainsAll tells if all of n are in the set.
+// It is false if any of n is negative.
+func (bs BitSet) ContainsAll(n ...int) bool {
+	for _, e := range n {
+		if !bs.Contains(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny tells if at least one of n is in the set.
+// Negative n are skipped.
+func (bs BitSet) ContainsAny(n ...int) bool {
+	for _, e := range n {
+		if bs.Contains(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsRange tells if all integers from m to n-1 are in the set.
+// It is true if m>=n and false if the range includes negative integers.
+func (bs BitSet) ContainsRange(m, n int) bool {
+	if m >= n {
+		return true
+	}
+	if m < 0 {
 		return false
 	}
-	i := n >> shift
-	if i >= len(bs) {
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, n>>shift
+	if high >= len(bs) {
+		return false
+	}
+	if low == high {
+		mask := bitMask(m&div64rem, n&div64rem)
+		return bs[low]&mask == mask
+	}
+	if mask := bitMask(m&div64rem, bpw-1); bs[low]&mask != mask {
 		return false
 	}
-	return (bs[i] & (1 << uint(n&div64rem))) != 0
+	for i := low + 1; i < high; i++ {
+		if bs[i] != maxw {
+			return false
+		}
+	}
+	mask := bitMask(0, n&div64rem)
+	return bs[high]&mask == mask
 }
 
 // Equal tells if bs and other are equal.
+// Trailing zero words are insignificant.
 func (bs BitSet) Equal(other BitSet) bool {
 	if len(bs) != len(other) {
-		return false
+		bs, other = bs[:bs.trimmedLen()], other[:other.trimmedLen()]
+		if len(bs) != len(other) {
+			return false
+		}
 	}
 	for i := range bs {
 		if bs[i] != other[i] {
@@ -78,41 +273,268 @@ func (bs BitSet) Equal(other BitSet) bool {
 	return true
 }
 
+// Cmp compares bs and other as if they were big integers with bit n set for
+// every element n, and returns -1 if bs < other, 0 if bs == other and +1 if
+// bs > other. Trailing zero words are insignificant, so Cmp returns 0
+// if and only if bs and other contain the same elements.
+func (bs BitSet) Cmp(other BitSet) int {
+	i, j := bs.trimmedLen(), other.trimmedLen()
+	if i != j {
+		if i < j {
+			return -1
+		}
+		return 1
+	}
+	for i--; i >= 0; i-- {
+		if bs[i] != other[i] {
+			if bs[i] < other[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// EqualRange tells if a and b contain the same elements from m to n-1,
+// ignoring the elements outside of that range (true if m>=n).
+// Words beyond the length of a set are treated as zero.
+func EqualRange(a, b BitSet, m, n int) bool {
+	if n < 1 || m >= n {
+		return true
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, min(n>>shift, max(len(a), len(b))-1)
+	for i := low; i <= high; i++ {
+		if (a.Word(i)^b.Word(i))&windowMask(i, m, n) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// FirstDiff returns the smallest element that is in exactly one of a and b,
+// or -1 if they are equal. It doesn't allocate.
+func FirstDiff(a, b BitSet) int {
+	if len(a) > len(b) {
+		a, b = b, a // make a the shorter set
+	}
+	for i, w := range a {
+		if d := w ^ b[i]; d != 0 {
+			return i<<shift + bits.TrailingZeros64(d)
+		}
+	}
+	for i := len(a); i < len(b); i++ {
+		if b[i] != 0 {
+			return i<<shift + bits.TrailingZeros64(b[i])
+		}
+	}
+	return -1
+}
+
+// LastDiff returns the greatest element that is in exactly one of a and b,
+// or -1 if they are equal. It doesn't allocate. Trailing zero words
+// are insignificant.
+func LastDiff(a, b BitSet) int {
+	for i := max(len(a), len(b)) - 1; i >= 0; i-- {
+		if d := a.Word(i) ^ b.Word(i); d != 0 {
+			return i<<shift + bpw - 1 - bits.LeadingZeros64(d)
+		}
+	}
+	return -1
+}
+
+// trimmedLen returns the length of bs without trailing zero words.
+func (bs BitSet) trimmedLen() int {
+	i := len(bs)
+	for i > 0 && bs[i-1] == 0 {
+		i--
+	}
+	return i
+}
+
 // Subset tells if bs is a subset of other.
 func (bs BitSet) Subset(other BitSet) bool {
-	if len(bs) > len(other) {
-		return false
-	}
-	for i := range bs {
+	minLen := min(len(bs), len(other))
+	for i := 0; i < minLen; i++ {
 		if bs[i]&^other[i] != 0 {
 			return false
 		}
 	}
+	for i := minLen; i < len(bs); i++ { // words of bs beyond other must be zero
+		if bs[i] != 0 {
+			return false
+		}
+	}
 	return true
 }
 
+// ProperSubset tells if bs is a subset of other and other has
+// at least one element that is not in bs.
+func (bs BitSet) ProperSubset(other BitSet) bool {
+	minLen := min(len(bs), len(other))
+	proper := false
+	for i := 0; i < minLen; i++ {
+		if bs[i]&^other[i] != 0 {
+			return false
+		}
+		if bs[i] != other[i] {
+			proper = true
+		}
+	}
+	for i := minLen; i < len(bs); i++ {
+		if bs[i] != 0 {
+			return false
+		}
+	}
+	for i := minLen; i < len(other) && !proper; i++ {
+		proper = other[i] != 0
+	}
+	return proper
+}
+
+// Superset tells if bs is a superset of other.
+func (bs BitSet) Superset(other BitSet) bool {
+	return other.Subset(bs)
+}
+
+// ProperSuperset tells if bs is a superset of other and bs has
+// at least one element that is not in other.
+func (bs BitSet) ProperSuperset(other BitSet) bool {
+	return other.ProperSubset(bs)
+}
+
+// Intersects tells if bs and other have at least one element in common.
+func (bs BitSet) Intersects(other BitSet) bool {
+	minLen := min(len(bs), len(other))
+	for i := 0; i < minLen; i++ {
+		if bs[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Disjoint tells if bs and other have no elements in common.
+func (bs BitSet) Disjoint(other BitSet) bool {
+	return !bs.Intersects(other)
+}
+
+// Min returns the minimum element of the bitset.
+// If the set is empty, -1 is returned.
+func (bs BitSet) Min() int {
+	for i, w := range bs {
+		if w != 0 {
+			return (i << shift) + bits.TrailingZeros64(w)
+		}
+	}
+	return -1
+}
+
 // Max returns the maximum element of the bitset.
 // If the set is empty, -1 is returned.
 func (bs BitSet) Max() int {
-	if len(bs) == 0 {
+	i := bs.trimmedLen() - 1
+	if i < 0 {
 		return -1
 	}
-	i := len(bs) - 1
 	return (i << shift) + bits.Len64(bs[i]) - 1
 }
 
 // Size returns the number of elements in the set.
 func (bs BitSet) Size() int {
-	size := 0
-	for _, word := range bs {
-		size += bits.OnesCount64(word)
+	return popcntWords(bs)
+}
+
+// CountRange returns the number of elements e, m ≤ e < n, in the set.
+func (bs BitSet) CountRange(m, n int) int {
+	if n < 1 || m >= n {
+		return 0
 	}
-	return size
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, n>>shift
+	if low >= len(bs) {
+		return 0
+	}
+	if high >= len(bs) {
+		high = len(bs) - 1
+		n = bpw - 1
+	}
+	if low == high {
+		return bits.OnesCount64(bs[low] & bitMask(m&div64rem, n&div64rem))
+	}
+	count := bits.OnesCount64(bs[low] & bitMask(m&div64rem, bpw-1))
+	count += popcntWords(bs[low+1 : high])
+	count += bits.OnesCount64(bs[high] & bitMask(0, n&div64rem))
+	return count
+}
+
+// Rank returns the number of elements e, e < n, in the set.
+func (bs BitSet) Rank(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	i := n >> shift
+	if i >= len(bs) {
+		return bs.Size()
+	}
+	rank := 0
+	for _, w := range bs[:i] {
+		rank += bits.OnesCount64(w)
+	}
+	return rank + bits.OnesCount64(bs[i]&(1<<uint(n&div64rem)-1))
+}
+
+// Select returns the k-th smallest element of the set, counting from 0,
+// or -1 if there is no such element. Select(Rank(n)) == n for every n in the set.
+func (bs BitSet) Select(k int) int {
+	if k < 0 {
+		return -1
+	}
+	for i, w := range bs {
+		c := bits.OnesCount64(w)
+		if k < c {
+			return (i << shift) + selectInWord(w, k)
+		}
+		k -= c
+	}
+	return -1
+}
+
+// Quantile returns the element of rank floor(q*(Size()-1)), counting
+// from 0, so that Quantile(0) is Min() and Quantile(1) is Max().
+// It returns -1 if bs is empty or q is not within [0, 1].
+func (bs BitSet) Quantile(q float64) int {
+	if !(q >= 0 && q <= 1) {
+		return -1
+	}
+	size := bs.Size()
+	if size == 0 {
+		return -1
+	}
+	return bs.Select(int(q * float64(size-1)))
+}
+
+// Median returns the element of rank Size()/2, counting from 0, which is
+// the upper of the two middle elements if the size is even, or -1 if bs
+// is empty.
+func (bs BitSet) Median() int {
+	return bs.Select(bs.Size() / 2)
+}
+
+// selectInWord returns the position of the k-th set bit of w, 0 ≤ k < OnesCount64(w).
+func selectInWord(w uint64, k int) int {
+	for ; k > 0; k-- {
+		w &= w - 1 // clear the lowest set bit
+	}
+	return bits.TrailingZeros64(w)
 }
 
 // Empty tells if the set is empty.
 func (bs BitSet) Empty() bool {
-	return len(bs) == 0
+	return bs.trimmedLen() == 0
 }
 
 // Next returns the next element n, n > m, in the set,
@@ -121,55 +543,155 @@ func (bs BitSet) Next(m int) int {
 	if len(bs) == 0 {
 		return -1
 	}
-	l := len(bs)
 	if m < 0 {
-		if bs.Contains(0) {
+		if bs[0]&1 != 0 {
 			return 0
 		}
 		m = 0
 	}
-	i := m >> shift
-	if i >= l {
+	i := uint(m) >> shift
+	if i >= uint(len(bs)) {
 		return -1
 	}
 	t := uint(m&div64rem) + 1 // the next bit position after m in the word
 	w := bs[i] >> t << t      // zero out bits for numbers ≤ m
-	for i < l-1 && w == 0 {
+	for w == 0 {
 		i++
+		if i >= uint(len(bs)) {
+			return -1
+		}
+		w = bs[i]
+	}
+	return int(i<<shift) + bits.TrailingZeros64(w)
+}
+
+// NextMany appends the elements n, n > m, of the set to buf in ascending
+// order until buf is full, that is, up to cap(buf)-len(buf) elements,
+// and returns the extended buffer. Paging through the set by passing
+// the last returned element as m costs O(words visited) in total.
+func (bs BitSet) NextMany(m int, buf []int) []int {
+	if len(buf) == cap(buf) {
+		return buf
+	}
+	if m < 0 {
+		m = 0
+	} else if m < len(bs)<<shift {
+		m++ // the first candidate
+	}
+	i := m >> shift
+	if i >= len(bs) {
+		return buf
+	}
+	w := bs[i] >> uint(m&div64rem) << uint(m&div64rem) // zero out bits for numbers < m
+	for {
+		for ; w != 0; w &= w - 1 {
+			buf = append(buf, (i<<shift)+bits.TrailingZeros64(w))
+			if len(buf) == cap(buf) {
+				return buf
+			}
+		}
+		if i++; i >= len(bs) {
+			return buf
+		}
 		w = bs[i]
 	}
-	if w == 0 {
+}
+
+// Ceil returns the smallest element n, n ≥ m, in the set, or -1 if there
+// is no such element. A negative m is treated as 0.
+func (bs BitSet) Ceil(m int) int {
+	m = max(0, m)
+	i := uint(m) >> shift
+	if i >= uint(len(bs)) {
 		return -1
 	}
-	return (i << shift) + bits.TrailingZeros64(w)
+	t := uint(m & div64rem)
+	w := bs[i] >> t << t // zero out bits for numbers < m
+	for w == 0 {
+		i++
+		if i >= uint(len(bs)) {
+			return -1
+		}
+		w = bs[i]
+	}
+	return int(i<<shift) + bits.TrailingZeros64(w)
+}
+
+// Floor returns the greatest element n, n ≤ m, in the set, or -1 if there
+// is no such element.
+func (bs BitSet) Floor(m int) int {
+	if m < 0 || len(bs) == 0 {
+		return -1
+	}
+	i := m >> shift
+	t := bpw - 1 - uint(m&div64rem)
+	if i >= len(bs) {
+		i, t = len(bs)-1, 0
+	}
+	w := bs[i] << t >> t // zero out bits for numbers > m
+	for w == 0 {
+		if i == 0 {
+			return -1
+		}
+		i--
+		w = bs[i]
+	}
+	return (i << shift) + bits.Len64(w) - 1
 }
 
 // Prev returns the previous element n, n < m, in the set,
 // or -1 if there is no such element.
 func (bs BitSet) Prev(m int) int {
-	if len(bs) == 0 || m <= 0 {
+	if m <= 0 {
 		return -1
 	}
-	l := len(bs)
-	lastIdx := l - 1
-	maxPossible := (lastIdx << shift) + bits.Len64(bs[lastIdx]) - 1
-	if m > maxPossible {
-		return maxPossible
+	return bs.Floor(m - 1)
+}
+
+// NextClear returns the next integer n, n > m, not in the set,
+// or -1 if m is math.MaxInt. Every integer greater than Max()
+// is not in the set.
+func (bs BitSet) NextClear(m int) int {
+	if m == math.MaxInt {
+		return -1
 	}
-	i := m >> shift
-	t := bpw - uint(m&div64rem)
-	if i >= l {
-		i = l - 1
-		t = bpw
+	n := max(0, m+1)
+	i := n >> shift
+	if i >= len(bs) {
+		return n
 	}
-	w := bs[i] << t >> t // zero out bits >= m
-	for i > 0 && w == 0 {
-		i--
-		w = bs[i]
+	t := uint(n & div64rem)
+	w := ^bs[i] >> t << t // zero out bits for numbers < n
+	for w == 0 {
+		i++
+		if i >= len(bs) {
+			return i << shift
+		}
+		w = ^bs[i]
 	}
-	if w == 0 {
+	return (i << shift) + bits.TrailingZeros64(w)
+}
+
+// PrevClear returns the previous integer n, 0 ≤ n < m, not in the set,
+// or -1 if there is no such integer.
+func (bs BitSet) PrevClear(m int) int {
+	if m <= 0 {
 		return -1
 	}
+	n := m - 1
+	i := n >> shift
+	if i >= len(bs) {
+		return n
+	}
+	t := uint(bpw - 1 - n&div64rem)
+	w := ^bs[i] << t >> t // zero out bits for numbers > n
+	for w == 0 {
+		if i == 0 {
+			return -1
+		}
+		i--
+		w = ^bs[i]
+	}
 	return (i << shift) + bits.Len64(w) - 1
 }
 
@@ -177,7 +699,7 @@ func (bs BitSet) Prev(m int) int {
 // If do returns true, Visit returns immediately, skipping any remaining
 // elements, and returns true. It is safe for do to add or delete
 // elements e, e ≤ n. The behavior of Visit is undefined if do changes
-// the set in any other way.
+// the set in any other way; use VisitGrowing if it does.
 func (bs BitSet) Visit(do func(n int) bool) (aborted bool) {
 	for i, l := 0, len(bs); i < l; i++ {
 		w := bs[i]
@@ -202,14 +724,304 @@ func (bs BitSet) Visit(do func(n int) bool) (aborted bool) {
 	return false
 }
 
-// VisitAll calls do function for each element of s in numerical order.
-func (bs BitSet) VisitAll(do func(n int)) {
+// Every tells if pred returns true for every element of bs, stopping at the
+// first element for which it returns false. It returns true if bs is empty.
+func (bs BitSet) Every(pred func(n int) bool) bool {
+	return !bs.Visit(func(n int) bool { return !pred(n) })
+}
+
+// Any tells if pred returns true for some element of bs, stopping at the
+// first such element. It returns false if bs is empty.
+func (bs BitSet) Any(pred func(n int) bool) bool {
+	return bs.Visit(pred)
+}
+
+// None tells if pred returns false for every element of bs, stopping at the
+// first element for which it returns true. It returns true if bs is empty.
+func (bs BitSet) None(pred func(n int) bool) bool {
+	return !bs.Visit(pred)
+}
+
+// VisitGrowing calls the do function for each element of *bs in numerical
+// order with the same abort semantics as Visit, but it is safe for do
+// to change the set arbitrarily: the next element is looked up in the
+// current contents of *bs after each call. Elements greater than n added
+// by do are visited later, and deleted ones are skipped, which makes
+// VisitGrowing suitable for worklist algorithms. Each element is visited
+// at most once.
+func (bs *BitSet) VisitGrowing(do func(n int) bool) (aborted bool) {
+	for n := bs.Next(-1); n >= 0; n = bs.Next(n) {
+		if do(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitRange calls the do function for each element e, m ≤ e < n, of s
+// in numerical order. The abort semantics and the allowed changes
+// of the set during the visit are the same as for Visit.
+func (bs BitSet) VisitRange(m, n int, do func(n int) bool) (aborted bool) {
+	if n < 1 || m >= n {
+		return false
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, n>>shift
+	if low >= len(bs) {
+		return false
+	}
+	last := maxw
+	if high >= len(bs) {
+		high = len(bs) - 1
+	} else {
+		last = bitMask(0, n&div64rem)
+	}
+	for i := low; i <= high; i++ {
+		w := bs[i]
+		if i == low {
+			w &= bitMask(m&div64rem, bpw-1)
+		}
+		if i == high {
+			w &= last
+		}
+		for w != 0 {
+			if do((i << shift) + bits.TrailingZeros64(w)) {
+				return true
+			}
+			w &= w - 1 // clear the lowest set bit
+		}
+	}
+	return false
+}
+
+// VisitDescending calls the do function for each element of s in
+// descending numerical order. If do returns true, VisitDescending returns
+// immediately, skipping any remaining elements, and returns true.
+// It is safe for do to add or delete elements e, e ≥ n. The behavior of
+// VisitDescending is undefined if do changes the set in any other way.
+func (bs BitSet) VisitDescending(do func(n int) bool) (aborted bool) {
+	for i := len(bs) - 1; i >= 0; i-- {
+		w := bs[i]
+		for w != 0 {
+			b := bits.Len64(w) - 1
+			if do((i << shift) + b) {
+				return true
+			}
+			w &^= 1 << uint(b)
+		}
+	}
+	return false
+}
+
+// VisitAll calls do function for each element of s in numerical order.
+func (bs BitSet) VisitAll(do func(n int)) {
 	bs.Visit(func(n int) bool {
 		do(n)
 		return false
 	})
 }
 
+// All returns an iterator over the elements of the set in ascending order.
+func (bs BitSet) All() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i, w := range bs {
+			for w != 0 {
+				if !yield((i << shift) + bits.TrailingZeros64(w)) {
+					return
+				}
+				w &= w - 1 // clear the lowest set bit
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the elements of the set in descending order.
+func (bs BitSet) Backward() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := len(bs) - 1; i >= 0; i-- {
+			w := bs[i]
+			for w != 0 {
+				b := bits.Len64(w) - 1
+				if !yield((i << shift) + b) {
+					return
+				}
+				w &^= 1 << uint(b)
+			}
+		}
+	}
+}
+
+// Ranges returns an iterator over the maximal runs of consecutive elements
+// of the set in ascending order. Each run is yielded as an inclusive range
+// [start, end].
+func (bs BitSet) Ranges() iter.Seq2[int, int] {
+	return func(yield func(start, end int) bool) {
+		for start := bs.Next(-1); start >= 0; {
+			end := bs.NextClear(start) - 1
+			if !yield(start, end) {
+				return
+			}
+			start = bs.Next(end)
+		}
+	}
+}
+
+// Gaps returns an iterator over the maximal runs of integers absent
+// from the set strictly between Min() and Max(), in ascending order.
+// Each run is yielded as an inclusive range [start, end].
+func (bs BitSet) Gaps() iter.Seq2[int, int] {
+	return func(yield func(start, end int) bool) {
+		for n := bs.Next(-1); n >= 0; {
+			start := bs.NextClear(n)
+			n = bs.Next(start)
+			if n < 0 || !yield(start, n-1) {
+				return
+			}
+		}
+	}
+}
+
+// LongestRun returns the smallest start and the length of the longest run
+// of consecutive elements of the set, or (-1, 0) if the set is empty.
+func (bs BitSet) LongestRun() (start, length int) {
+	start = -1
+	for n := bs.Next(-1); n >= 0; {
+		end := bs.NextClear(n)
+		if end-n > length {
+			start, length = n, end-n
+		}
+		n = bs.Next(end)
+	}
+	return start, length
+}
+
+// LongestGap returns the smallest start and the length of the longest run
+// of integers absent from the set strictly between Min() and Max(),
+// or (-1, 0) if there is no such integer.
+func (bs BitSet) LongestGap() (start, length int) {
+	start = -1
+	for n := bs.Next(-1); n >= 0; {
+		gap := bs.NextClear(n)
+		n = bs.Next(gap)
+		if n < 0 {
+			break
+		}
+		if n-gap > length {
+			start, length = gap, n-gap
+		}
+	}
+	return start, length
+}
+
+// NumRanges returns the number of maximal runs of consecutive elements in the set.
+func (bs BitSet) NumRanges() int {
+	count := 0
+	var carry uint64 // the highest bit of the previous word
+	for _, w := range bs {
+		count += bits.OnesCount64(w &^ (w<<1 | carry)) // bits that start a run
+		carry = w >> (bpw - 1)
+	}
+	return count
+}
+
+// IsContiguous tells if the set is empty or consists of a single run
+// of consecutive elements.
+func (bs BitSet) IsContiguous() bool {
+	count := 0
+	var carry uint64
+	for _, w := range bs {
+		count += bits.OnesCount64(w &^ (w<<1 | carry))
+		if count > 1 {
+			return false
+		}
+		carry = w >> (bpw - 1)
+	}
+	return true
+}
+
+// ToSlice returns the elements of the set in ascending order.
+func (bs BitSet) ToSlice() []int {
+	return bs.AppendTo(make([]int, 0, bs.Size()))
+}
+
+// AppendTo appends the elements of the set in ascending order to buf
+// and returns the extended buffer. It never returns nil.
+func (bs BitSet) AppendTo(buf []int) []int {
+	if buf == nil {
+		buf = []int{}
+	}
+	bs.VisitAll(func(n int) {
+		buf = append(buf, n)
+	})
+	return buf
+}
+
+// MaxN appends the k largest elements of the set in descending order
+// to buf and returns the extended buffer; all elements if k ≥ Size().
+// It never returns nil.
+func (bs BitSet) MaxN(k int, buf []int) []int {
+	if buf == nil {
+		buf = []int{}
+	}
+	for i := len(bs) - 1; i >= 0 && k > 0; i-- {
+		for w := bs[i]; w != 0 && k > 0; k-- {
+			j := bits.Len64(w) - 1
+			buf = append(buf, i<<shift+j)
+			w &^= 1 << uint(j)
+		}
+	}
+	return buf
+}
+
+// MinN appends the k smallest elements of the set in ascending order
+// to buf and returns the extended buffer; all elements if k ≥ Size().
+// It never returns nil.
+func (bs BitSet) MinN(k int, buf []int) []int {
+	if buf == nil {
+		buf = []int{}
+	}
+	for i := 0; i < len(bs) && k > 0; i++ {
+		for w := bs[i]; w != 0 && k > 0; k-- {
+			buf = append(buf, i<<shift+bits.TrailingZeros64(w))
+			w &= w - 1 // clear the lowest set bit
+		}
+	}
+	return buf
+}
+
+// ToBools returns a mask of length n where the element at index i is true
+// if i is in the set. Elements greater than or equal to n are ignored.
+// If n is negative, the length of the mask is Max()+1.
+func (bs BitSet) ToBools(n int) []bool {
+	if n < 0 {
+		n = bs.Max() + 1
+	}
+	b := make([]bool, n)
+	for i, w := range bs[:min(len(bs), (n+bpw-1)>>shift)] {
+		for ; w != 0; w &= w - 1 {
+			if e := (i << shift) + bits.TrailingZeros64(w); e < n {
+				b[e] = true
+			}
+		}
+	}
+	return b
+}
+
+// Words returns a copy of the words of the set, see FromWords.
+func (bs BitSet) Words() []uint64 {
+	return []uint64(bs.Copy())
+}
+
+// Word returns the i-th word of the set, holding the elements from 64*i
+// to 64*i + 63, or 0 if i is out of range.
+func (bs BitSet) Word(i int) uint64 {
+	if i < 0 || i >= len(bs) {
+		return 0
+	}
+	return bs[i]
+}
+
 // bitMask returns a uint64 with bits set from start to end inclusive, 0 ≤ start ≤ end < bpw.
 func bitMask(start, end int) uint64 {
 	return maxw >> uint(bpw-1-(end-start)) << uint(start)
@@ -239,9 +1051,95 @@ func (bs *BitSet) resize(n int) {
 		newData := make(BitSet, n, newCap(n, cap(*bs)))
 		copy(newData, *bs)
 		*bs = newData
+		return
 	}
-	for i := len(*bs) - 1; i >= n && i >= 0; i-- {
-		(*bs)[i] = 0
+	// Zero the words dropped or exposed, so that no stale words stay
+	// beyond the length, nor come back if they were left by a re-slice.
+	if l := len(*bs); n < l {
+		clear((*bs)[n:l])
+	} else {
+		clear((*bs)[l:n])
+	}
+	*bs = (*bs)[:n]
+}
+
+// Grow ensures that bs can hold the elements up to n without
+// reallocation. The contents of bs are not changed. Grow panics
+// if n is greater than MaxElement.
+func (bs *BitSet) Grow(n int) {
+	if n < 0 {
+		return
+	}
+	mustFit(n)
+	words := (n >> shift) + 1
+	if cap(*bs) >= words {
+		return
+	}
+	newData := make(BitSet, len(*bs), newCap(words, cap(*bs)))
+	copy(newData, *bs)
+	*bs = newData
+}
+
+// Clip reallocates bs to release the capacity beyond its length,
+// if there is any. The contents of bs are not changed.
+func (bs *BitSet) Clip() {
+	if cap(*bs) == len(*bs) {
+		return
+	}
+	newData := make(BitSet, len(*bs))
+	copy(newData, *bs)
+	*bs = newData
+}
+
+// Cap returns the number of elements bs can hold without reallocation.
+func (bs BitSet) Cap() int {
+	return cap(bs) << shift
+}
+
+// ByteSize returns the memory footprint of bs in bytes: its capacity
+// in words plus the slice header.
+func (bs BitSet) ByteSize() int {
+	return cap(bs)*8 + int(unsafe.Sizeof(bs))
+}
+
+// Stats describes the memory usage and the layout of a set.
+type Stats struct {
+	WordsUsed   int     // length of the set in words
+	WordsCap    int     // capacity of the set in words
+	SetBits     int     // number of elements
+	Ranges      int     // number of maximal runs of consecutive elements
+	Density     float64 // SetBits / (64 * WordsUsed), 0 if WordsUsed is 0
+	WastedWords int     // zero words within the used words
+}
+
+// Stats returns the memory usage and the layout statistics of bs,
+// computed in one pass over its words.
+func (bs BitSet) Stats() Stats {
+	st := Stats{WordsUsed: len(bs), WordsCap: cap(bs)}
+	var carry uint64 // the highest bit of the previous word
+	for _, w := range bs {
+		if w == 0 {
+			st.WastedWords++
+		}
+		st.SetBits += bits.OnesCount64(w)
+		st.Ranges += bits.OnesCount64(w &^ (w<<1 | carry))
+		carry = w >> (bpw - 1)
+	}
+	if st.WordsUsed > 0 {
+		st.Density = float64(st.SetBits) / float64(st.WordsUsed*bpw)
+	}
+	return st
+}
+
+// extend grows *bs to n words, n ≥ len(*bs), reusing its capacity if possible.
+// The words beyond the previous length are not initialized,
+// so the caller must overwrite them.
+func (bs *BitSet) extend(n int) {
+	if cap(*bs) < n {
+		newData := make(BitSet, n, newCap(n, cap(*bs)))
+		copy(newData, *bs)
+		*bs = newData
+		return
 	}
 	*bs = (*bs)[:n]
 }
@@ -255,10 +1153,28 @@ func (bs *BitSet) trim() {
 	*bs = (*bs)[:i+1]
 }
 
-// Set replaces the contents of *bs with other.
+// Validate checks the invariants of bs and returns an error describing
+// the first violation: the last word must not be zero. The words beyond
+// the length are not checked, since every growing path overwrites or
+// zeroes them. Sets built with the functions of this package always
+// satisfy the invariants; Validate is meant for sets constructed from
+// raw words.
+func (bs BitSet) Validate() error {
+	if l := len(bs); l > 0 && bs[l-1] == 0 {
+		return fmt.Errorf("bitset: trailing zero word at index %d", l-1)
+	}
+	return nil
+}
+
+// Set replaces the contents of *bs with other,
+// reusing the capacity of *bs if possible. Other may share the backing
+// array of *bs, for example be a re-slice of it; setting *bs to itself
+// is a no-op.
 func (bs *BitSet) Set(other BitSet) {
-	*bs = make(BitSet, len(other))
-	copy(*bs, other)
+	if len(other) == len(*bs) && (len(other) == 0 || &other[0] == &(*bs)[0]) {
+		return
+	}
+	other.CopyTo(bs)
 }
 
 // Copy creates a new set that is a copy of bs.
@@ -271,78 +1187,580 @@ func (bs BitSet) Copy() BitSet {
 	return s
 }
 
-// Add adds n to bs (no-op if n < 0).
-func (bs *BitSet) Add(n int) {
-	if n < 0 {
+// CopyTo replaces the contents of *dst with bs, reusing the capacity of *dst
+// if possible. It is safe for *dst and bs to share the same backing array.
+func (bs BitSet) CopyTo(dst *BitSet) {
+	l := dst.reuse(len(bs))
+	copy(*dst, bs)
+	dst.clearTail(l)
+}
+
+// Add adds the given elements to bs, skipping negative ones.
+// The set is resized at most once. Add panics if an element
+// is greater than MaxElement.
+func (bs *BitSet) Add(n ...int) {
+	maxElem := -1
+	for _, e := range n {
+		if e > maxElem {
+			maxElem = e
+		}
+	}
+	if maxElem < 0 {
+		return
+	}
+	mustFit(maxElem)
+	if i := maxElem >> shift; i >= len(*bs) {
+		bs.resize(i + 1)
+	}
+	for _, e := range n {
+		if e >= 0 {
+			(*bs)[e>>shift] |= 1 << uint(e&div64rem)
+		}
+	}
+}
+
+// Delete removes the given elements from bs, skipping negative
+// and absent ones. The set is trimmed once at the end, and only if
+// its last word was modified and became zero.
+func (bs *BitSet) Delete(n ...int) {
+	l := len(*bs)
+	top := false // the last word was modified
+	for _, e := range n {
+		if e < 0 {
+			continue
+		}
+		if i := e >> shift; i < l {
+			(*bs)[i] &^= 1 << uint(e&div64rem)
+			top = top || i == l-1
+		}
+	}
+	if top && (*bs)[l-1] == 0 {
+		bs.trim()
+	}
+}
+
+// AddSlice adds the elements of ns to bs, skipping negative ones.
+// It is the bulk form of Add taking an existing slice: the maximum is
+// found in a single pass and bs is resized at most once. AddSlice panics
+// if an element is greater than MaxElement.
+func (bs *BitSet) AddSlice(ns []int) {
+	bs.Add(ns...)
+}
+
+// DeleteSlice removes the elements of ns from bs. It is the bulk form
+// of Delete taking an existing slice; bs is trimmed once at the end.
+func (bs *BitSet) DeleteSlice(ns []int) {
+	bs.Delete(ns...)
+}
+
+// DeleteNoTrim removes n from bs like Delete, but leaves trailing zero
+// words in place, so that a batch of deletes can be followed by
+// a single Trim. Queries such as Contains, Equal, Max and Size remain
+// correct in between.
+func (bs *BitSet) DeleteNoTrim(n int) {
+	if i := n >> shift; n >= 0 && i < len(*bs) {
+		(*bs)[i] &^= 1 << uint(n&div64rem)
+	}
+}
+
+// Trim removes the trailing zero words of bs left by DeleteNoTrim
+// and DeleteRangeNoTrim.
+func (bs *BitSet) Trim() {
+	bs.trim()
+}
+
+// SetBit adds n to bs if v is true and removes it otherwise (no-op if n < 0).
+func (bs *BitSet) SetBit(n int, v bool) {
+	if v {
+		bs.Add(n)
+	} else {
+		bs.Delete(n)
+	}
+}
+
+// TestAndSet adds n to bs and tells if it was already present (no-op if n < 0).
+// It panics if n is greater than MaxElement.
+func (bs *BitSet) TestAndSet(n int) bool {
+	if n < 0 {
+		return false
+	}
+	mustFit(n)
+	i := n >> shift
+	if i >= len(*bs) {
+		bs.resize(i + 1)
+	}
+	mask := uint64(1) << uint(n&div64rem)
+	w := (*bs)[i]
+	(*bs)[i] = w | mask
+	return w&mask != 0
+}
+
+// TestAndClear removes n from bs and tells if it was present (no-op if n < 0).
+func (bs *BitSet) TestAndClear(n int) bool {
+	if n < 0 {
+		return false
+	}
+	i := n >> shift
+	if i >= len(*bs) {
+		return false
+	}
+	mask := uint64(1) << uint(n&div64rem)
+	w := (*bs)[i]
+	if w&mask == 0 {
+		return false
+	}
+	(*bs)[i] = w &^ mask
+	bs.trim()
+	return true
+}
+
+// PopMin removes the minimum element from bs and returns it.
+// If the set is empty, -1 is returned.
+func (bs *BitSet) PopMin() int {
+	for i, w := range *bs {
+		if w != 0 {
+			(*bs)[i] = w & (w - 1) // clear the lowest set bit
+			if i == len(*bs)-1 {
+				bs.trim()
+			}
+			return (i << shift) + bits.TrailingZeros64(w)
+		}
+	}
+	return -1
+}
+
+// PopMax removes the maximum element from bs and returns it.
+// If the set is empty, -1 is returned.
+func (bs *BitSet) PopMax() int {
+	n := bs.Max()
+	if n < 0 {
+		return -1
+	}
+	(*bs)[n>>shift] &^= 1 << uint(n&div64rem)
+	bs.trim()
+	return n
+}
+
+// RemoveIf removes every element n of bs for which pred(n) returns true.
+// The elements are passed to pred in numerical order.
+func (bs *BitSet) RemoveIf(pred func(n int) bool) {
+	bs.filter(pred, true)
+}
+
+// RetainIf removes every element n of bs for which pred(n) returns false.
+// The elements are passed to pred in numerical order.
+func (bs *BitSet) RetainIf(pred func(n int) bool) {
+	bs.filter(pred, false)
+}
+
+// Partition creates two new sets: yes consists of the elements n of bs for
+// which pred(n) returns true and no of the rest. The elements are passed
+// to pred in numerical order.
+func (bs BitSet) Partition(pred func(n int) bool) (yes, no BitSet) {
+	yes, no = make(BitSet, len(bs)), make(BitSet, len(bs))
+	for i, w := range bs {
+		matched := uint64(0)
+		for v := w; v != 0; v &= v - 1 {
+			b := bits.TrailingZeros64(v)
+			if pred((i << shift) + b) {
+				matched |= 1 << uint(b)
+			}
+		}
+		yes[i], no[i] = matched, w&^matched
+	}
+	yes.trim()
+	no.trim()
+	return yes, no
+}
+
+// filter removes every element n of bs for which pred(n) == remove.
+func (bs *BitSet) filter(pred func(n int) bool, remove bool) {
+	s := *bs
+	for i, w := range s {
+		removed := uint64(0)
+		for v := w; v != 0; v &= v - 1 {
+			b := bits.TrailingZeros64(v)
+			if pred((i<<shift)+b) == remove {
+				removed |= 1 << uint(b)
+			}
+		}
+		s[i] = w &^ removed
+	}
+	bs.trim()
+}
+
+// Map creates a new set that consists of f(n) for every element n of bs,
+// dropping the negative results. The elements are passed to f once each
+// in numerical order. Map panics if a result is greater than MaxElement.
+func (bs BitSet) Map(f func(n int) int) BitSet {
+	if bs.Empty() {
+		return BitSet{}
+	}
+	var s BitSet
+	for i, w := range bs {
+		for ; w != 0; w &= w - 1 {
+			s.Add(f(i<<shift + bits.TrailingZeros64(w)))
+		}
+	}
+	return s
+}
+
+// Compact creates a new set that consists of index.Rank(n) for every
+// element n of bs that is in index, i.e. it renumbers the elements by
+// their position in index. The elements of bs not in index are dropped.
+func (bs BitSet) Compact(index BitSet) BitSet {
+	high := min(len(bs), len(index)) - 1
+	for high >= 0 && bs[high]&index[high] == 0 {
+		high--
+	}
+	if high < 0 {
+		return BitSet{}
+	}
+	maxRank := index.Rank(high<<shift + bits.Len64(bs[high]&index[high]) - 1)
+	s := make(BitSet, maxRank>>shift+1)
+	rank := 0 // the number of elements of index in the words before i
+	for i := 0; i <= high; i++ {
+		x := index[i]
+		for w := bs[i] & x; w != 0; w &= w - 1 {
+			b := bits.TrailingZeros64(w)
+			r := rank + bits.OnesCount64(x&(1<<uint(b)-1))
+			s[r>>shift] |= 1 << uint(r&div64rem)
+		}
+		rank += bits.OnesCount64(x)
+	}
+	return s
+}
+
+// AddRange adds all integers from m to n-1 to bs (no-op if m>=n).
+// It panics if n-1 is greater than MaxElement.
+func (bs *BitSet) AddRange(m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	mustFit(n)
+	low, high := m>>shift, n>>shift
+	if high >= len(*bs) {
+		bs.resize(high + 1)
+	}
+	if low == high {
+		(*bs)[low] |= bitMask(m&div64rem, n&div64rem)
+		return
+	}
+	(*bs)[low] |= bitMask(m&div64rem, bpw-1)
+	for i := low + 1; i < high; i++ {
+		(*bs)[i] = maxw
+	}
+	(*bs)[high] |= bitMask(0, n&div64rem)
+}
+
+// AddCapped adds the given elements to bs like Add, but returns an error
+// and leaves bs unchanged if any element exceeds maxAllowed or MaxElement.
+// It guards against huge allocations caused by untrusted input.
+func (bs *BitSet) AddCapped(maxAllowed int, n ...int) error {
+	for _, e := range n {
+		if err := checkElement(e, maxAllowed); err != nil {
+			return err
+		}
+	}
+	bs.Add(n...)
+	return nil
+}
+
+// AddRangeCapped adds all integers from m to n-1 to bs like AddRange,
+// but returns an error and leaves bs unchanged if n-1 exceeds maxAllowed
+// or MaxElement.
+func (bs *BitSet) AddRangeCapped(maxAllowed, m, n int) error {
+	if n < 1 || m >= n {
+		return nil
+	}
+	if err := checkElement(n-1, maxAllowed); err != nil {
+		return err
+	}
+	bs.AddRange(m, n)
+	return nil
+}
+
+// DeleteRange removes all integers from m to n-1 (no-op if m>=n).
+func (bs *BitSet) DeleteRange(m, n int) {
+	l := len(*bs)
+	bs.DeleteRangeNoTrim(m, n)
+	if l > 0 && n-1 >= (l-1)<<shift && (*bs)[l-1] == 0 { // the last word was cleared
+		bs.trim()
+	}
+}
+
+// DeleteRangeNoTrim removes all integers from m to n-1 like DeleteRange,
+// but leaves trailing zero words in place. See DeleteNoTrim.
+func (bs *BitSet) DeleteRangeNoTrim(m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, n>>shift
+	if low >= len(*bs) {
+		return
+	}
+	if high >= len(*bs) {
+		high = len(*bs) - 1
+		n = bpw - 1
+	}
+	if low == high {
+		(*bs)[low] &^= bitMask(m&div64rem, n&div64rem)
+		return
+	}
+	(*bs)[low] &^= bitMask(m&div64rem, bpw-1)
+	for i := low + 1; i < high; i++ {
+		(*bs)[i] = 0
+	}
+	(*bs)[high] &^= bitMask(0, n&div64rem)
+}
+
+// Extract creates a new set that consists of the elements of bs
+// from m to n-1. The elements keep their values; see Window for
+// a rebased variant.
+func (bs BitSet) Extract(m, n int) BitSet {
+	if n < 1 || m >= n {
+		return BitSet{}
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, min(n>>shift, len(bs)-1)
+	for high >= low && bs[high]&windowMask(high, m, n) == 0 {
+		high--
+	}
+	if high < low {
+		return BitSet{}
+	}
+	s := make(BitSet, high+1)
+	for i := low; i <= high; i++ {
+		s[i] = bs[i] & windowMask(i, m, n)
+	}
+	return s
+}
+
+// Window creates a new set that consists of the elements of bs
+// from m to n-1 decreased by m, so that m becomes 0. It is equivalent
+// to Extract followed by ShiftRight(m) without the intermediate set.
+// A negative m is treated as 0.
+func (bs BitSet) Window(m, n int) BitSet {
+	if n < 1 || m >= n {
+		return BitSet{}
+	}
+	m = max(0, m)
+	low := m >> shift
+	if low >= len(bs) {
+		return BitSet{}
+	}
+	n -= m + 1 // convert to inclusive range [0, n] of the result
+	b := uint(m & div64rem)
+	l := min(n>>shift+1, (len(bs)<<shift-m+bpw-1)>>shift)
+	s := make(BitSet, l)
+	for i := range s {
+		w := bs[low+i] >> b
+		if b > 0 && low+i+1 < len(bs) {
+			w |= bs[low+i+1] << (bpw - b)
+		}
+		s[i] = w
+	}
+	if l-1 == n>>shift {
+		s[l-1] &= bitMask(0, n&div64rem)
+	}
+	s.trim()
+	return s
+}
+
+// Split creates two new sets: lo consists of the elements of bs
+// less than n and hi of the rest.
+func (bs BitSet) Split(n int) (lo, hi BitSet) {
+	lo = bs.Copy()
+	lo.TruncateAt(n)
+	i := max(0, n) >> shift
+	if i >= len(bs) {
+		return lo, BitSet{}
+	}
+	hi = make(BitSet, len(bs))
+	copy(hi[i:], bs[i:])
+	if n > 0 {
+		hi[i] &^= 1<<uint(n&div64rem) - 1
+	}
+	hi.trim()
+	return lo, hi
+}
+
+// TruncateAt removes all elements of bs that are greater than
+// or equal to n.
+func (bs *BitSet) TruncateAt(n int) {
+	if n < 1 {
+		bs.Reset()
 		return
 	}
 	i := n >> shift
 	if i >= len(*bs) {
-		bs.resize(i + 1)
+		return
+	}
+	s := *bs
+	if b := uint(n & div64rem); b > 0 {
+		s[i] &= 1<<b - 1
+		i++
+	}
+	clear(s[i:])
+	*bs = s[:i]
+	bs.trim()
+}
+
+// KeepRange removes all elements of bs outside of m to n-1.
+// If m>=n, bs becomes empty.
+func (bs *BitSet) KeepRange(m, n int) {
+	if n < 1 || m >= n {
+		bs.Reset()
+		return
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, n>>shift
+	if low >= len(*bs) {
+		bs.Reset()
+		return
 	}
-	(*bs)[i] |= 1 << uint(n&div64rem)
+	s := *bs
+	if high < len(s) {
+		clear(s[high+1:])
+		s = s[:high+1]
+		s[high] &= bitMask(0, n&div64rem)
+	}
+	clear(s[:low])
+	s[low] &= bitMask(m&div64rem, bpw-1)
+	*bs = s
+	bs.trim()
 }
 
-// Delete removes n from bs (no-op if n < 0 or not present).
-func (bs *BitSet) Delete(n int) {
+// Flip adds n to bs if it is absent and removes it otherwise (no-op if n < 0).
+// It panics if n is greater than MaxElement.
+func (bs *BitSet) Flip(n int) {
 	if n < 0 {
 		return
 	}
+	mustFit(n)
 	i := n >> shift
 	if i >= len(*bs) {
-		return
+		bs.resize(i + 1)
 	}
-	(*bs)[i] &^= 1 << uint(n&div64rem)
+	(*bs)[i] ^= 1 << uint(n&div64rem)
 	bs.trim()
 }
 
-// AddRange adds all integers from m to n-1 to bs (no-op if m>=n).
-func (bs *BitSet) AddRange(m, n int) {
+// FlipRange flips all integers from m to n-1 in bs (no-op if m>=n).
+// It panics if n-1 is greater than MaxElement.
+func (bs *BitSet) FlipRange(m, n int) {
 	if n < 1 || m >= n {
 		return
 	}
 	m = max(0, m)
 	n-- // convert to inclusive range [m, n]
+	mustFit(n)
 	low, high := m>>shift, n>>shift
 	if high >= len(*bs) {
 		bs.resize(high + 1)
 	}
 	if low == high {
-		(*bs)[low] |= bitMask(m&div64rem, n&div64rem)
+		(*bs)[low] ^= bitMask(m&div64rem, n&div64rem)
+		bs.trim()
 		return
 	}
-	(*bs)[low] |= bitMask(m&div64rem, bpw-1)
+	(*bs)[low] ^= bitMask(m&div64rem, bpw-1)
 	for i := low + 1; i < high; i++ {
-		(*bs)[i] = maxw
+		(*bs)[i] = ^(*bs)[i]
 	}
-	(*bs)[high] |= bitMask(0, n&div64rem)
+	(*bs)[high] ^= bitMask(0, n&div64rem)
+	bs.trim()
 }
 
-// DeleteRange removes all integers from m to n-1 (no-op if m>=n).
-func (bs *BitSet) DeleteRange(m, n int) {
-	if n < 1 || m >= n {
+// Complement creates a new set that consists of all integers
+// from 0 to n-1 that are not in bs. It panics if n-1 is greater
+// than MaxElement.
+func (bs BitSet) Complement(n int) BitSet {
+	if n < 1 {
+		return BitSet{}
+	}
+	n-- // convert to inclusive range [0, n]
+	mustFit(n)
+	high := n >> shift
+	s := make(BitSet, high+1)
+	for i := range s {
+		if i < len(bs) {
+			s[i] = ^bs[i]
+		} else {
+			s[i] = maxw
+		}
+	}
+	s[high] &= bitMask(0, n&div64rem)
+	s.trim()
+	return s
+}
+
+// ComplementRange replaces the elements of bs within m to n-1 by
+// the integers of that range that were not in bs (no-op if m>=n).
+// Elements outside the range are kept intact.
+func (bs *BitSet) ComplementRange(m, n int) {
+	bs.FlipRange(m, n)
+}
+
+// ShiftLeft adds k to every element of bs (no-op if k ≤ 0).
+// It panics if an element would become greater than MaxElement.
+func (bs *BitSet) ShiftLeft(k int) {
+	l := len(*bs)
+	if k <= 0 || l == 0 {
 		return
 	}
-	m = max(0, m)
-	n-- // convert to inclusive range [m, n]
-	low, high := m>>shift, n>>shift
-	if low >= len(*bs) {
+	if m := bs.Max(); m >= 0 && k > maxElement-m {
+		panic(fmt.Errorf("bitset: element %d shifted by %d exceeds the maximum of %d", m, k, maxElement))
+	}
+	words, b := k>>shift, uint(k&div64rem)
+	if b == 0 {
+		bs.extend(l + words)
+		s := *bs
+		copy(s[words:], s[:l])
+		clear(s[:words])
 		return
 	}
-	if high >= len(*bs) {
-		high = len(*bs) - 1
-		n = bpw - 1
+	bs.extend(l + words + 1)
+	s := *bs
+	s[l+words] = s[l-1] >> (bpw - b)
+	for i := l - 1; i > 0; i-- {
+		s[i+words] = s[i]<<b | s[i-1]>>(bpw-b)
 	}
-	if low == high {
-		(*bs)[low] &^= bitMask(m&div64rem, n&div64rem)
-		bs.trim()
+	s[words] = s[0] << b
+	clear(s[:words])
+	bs.trim()
+}
+
+// ShiftRight subtracts k from every element of bs, dropping the elements
+// that become negative (no-op if k ≤ 0).
+func (bs *BitSet) ShiftRight(k int) {
+	l := len(*bs)
+	if k <= 0 || l == 0 {
 		return
 	}
-	(*bs)[low] &^= bitMask(m&div64rem, bpw-1)
-	for i := low + 1; i < high; i++ {
-		(*bs)[i] = 0
+	words, b := k>>shift, uint(k&div64rem)
+	if words >= l {
+		bs.Reset()
+		return
 	}
-	(*bs)[high] &^= bitMask(0, n&div64rem)
+	s := *bs
+	n := l - words
+	if b == 0 {
+		copy(s, s[words:])
+	} else {
+		for i := 0; i < n-1; i++ {
+			s[i] = s[i+words]>>b | s[i+words+1]<<(bpw-b)
+		}
+		s[n-1] = s[l-1] >> b
+	}
+	clear(s[n:])
+	*bs = s[:n]
 	bs.trim()
 }
 
@@ -366,35 +1784,8 @@ func And(s1, s2 BitSet) BitSet {
 // And keeps only bits set in both *bs and other.
 func (bs *BitSet) And(other BitSet) {
 	minLen := min(len(*bs), len(other))
-	if minLen < 8 {
-		for i := 0; i < minLen; i++ {
-			(*bs)[i] &= other[i]
-		}
-		for i := minLen; i < len(*bs); i++ {
-			(*bs)[i] = 0
-		}
-		bs.trim()
-		return
-	}
-
-	b := (*bs)[:minLen]
-	o := other[:minLen]
-	for ; len(o) > 7; b, o = b[8:], o[8:] {
-		b[0] &= o[0]
-		b[1] &= o[1]
-		b[2] &= o[2]
-		b[3] &= o[3]
-		b[4] &= o[4]
-		b[5] &= o[5]
-		b[6] &= o[6]
-		b[7] &= o[7]
-	}
-	for i := range o {
-		b[i] &= o[i]
-	}
-	for i := minLen; i < len(*bs); i++ {
-		(*bs)[i] = 0
-	}
+	andWords(*bs, other[:minLen])
+	clear((*bs)[minLen:])
 	bs.trim()
 }
 
@@ -442,32 +1833,12 @@ func Or(s1, s2 BitSet) BitSet {
 
 // Or sets bits that are set in either *bs or other.
 func (bs *BitSet) Or(other BitSet) {
-	if len(other) > len(*bs) {
-		bs.resize(len(other))
-	}
-	if len(other) < 8 {
-		for i := range other {
-			(*bs)[i] |= other[i]
-		}
-		bs.trim()
-		return
-	}
-
-	b := *bs
-	o := other
-	for ; len(o) > 7; b, o = b[8:], o[8:] {
-		b[0] |= o[0]
-		b[1] |= o[1]
-		b[2] |= o[2]
-		b[3] |= o[3]
-		b[4] |= o[4]
-		b[5] |= o[5]
-		b[6] |= o[6]
-		b[7] |= o[7]
-	}
-	for i := range o {
-		b[i] |= o[i]
+	if l := len(*bs); len(other) > l {
+		bs.extend(len(other))
+		copy((*bs)[l:], other[l:]) // the words beyond l are taken from other as is
+		other = other[:l]
 	}
+	orWords(*bs, other)
 	bs.trim()
 }
 
@@ -478,8 +1849,12 @@ func Xor(s1, s2 BitSet) BitSet {
 	}
 	bsLen, otherLen := len(s1), len(s2)
 	n := bsLen - 1
-	for n >= 0 { // find the last non-zero word in s1 or s2
-		if s1[n] != 0 || n < otherLen && s2[n] != 0 {
+	for n >= 0 { // find the last non-zero word of the result
+		w := s1[n]
+		if n < otherLen {
+			w ^= s2[n]
+		}
+		if w != 0 {
 			break
 		}
 		n--
@@ -500,31 +1875,12 @@ func Xor(s1, s2 BitSet) BitSet {
 
 // Xor toggles bits that are set in either *bs or other but not both.
 func (bs *BitSet) Xor(other BitSet) {
-	if len(other) > len(*bs) {
-		bs.resize(len(other))
-	}
-	if len(other) < 8 {
-		for i := range other {
-			(*bs)[i] ^= other[i]
-		}
-		bs.trim()
-		return
-	}
-
-	b := *bs
-	for ; len(other) > 7; b, other = b[8:], other[8:] {
-		(b)[0] ^= other[0]
-		(b)[1] ^= other[1]
-		(b)[2] ^= other[2]
-		(b)[3] ^= other[3]
-		(b)[4] ^= other[4]
-		(b)[5] ^= other[5]
-		(b)[6] ^= other[6]
-		(b)[7] ^= other[7]
-	}
-	for i := range other {
-		b[i] ^= other[i]
+	if l := len(*bs); len(other) > l {
+		bs.extend(len(other))
+		copy((*bs)[l:], other[l:]) // the words beyond l are taken from other as is
+		other = other[:l]
 	}
+	xorWords(*bs, other)
 	bs.trim()
 }
 
@@ -535,7 +1891,14 @@ func AndNot(s1, s2 BitSet) BitSet {
 		return BitSet{}
 	}
 	n := bsLen - 1
-	for n >= 0 && s1[n] == 0 { // find the last non-zero word in s1, we care only about s1 because AND NOT is always zero when s1 is zero
+	for n >= 0 { // find the last non-zero word of the result
+		w := s1[n]
+		if n < otherLen {
+			w &^= s2[n]
+		}
+		if w != 0 {
+			break
+		}
 		n--
 	}
 	if n < 0 {
@@ -554,44 +1917,381 @@ func AndNot(s1, s2 BitSet) BitSet {
 
 // AndNot removes bits that are set in other from *bs.
 func (bs *BitSet) AndNot(other BitSet) {
-	minLen := min(len(*bs), len(other))
-	if minLen < 8 {
-		for i := 0; i < minLen; i++ {
-			(*bs)[i] &^= other[i]
+	andNotWords(*bs, other[:min(len(*bs), len(other))])
+	bs.trim()
+}
+
+// OrChanged adds the elements of other to *bs like Or and tells if at least
+// one of them was not in *bs.
+func (bs *BitSet) OrChanged(other BitSet) bool {
+	other = other[:other.trimmedLen()]
+	n := min(len(*bs), len(other))
+	s, o := (*bs)[:n], other[:n]
+	var diff uint64 // the bits flipped from 0 to 1
+	for i, w := range s {
+		diff |= o[i] &^ w
+		s[i] = w | o[i]
+	}
+	if l := len(*bs); len(other) > l {
+		// The last word of other is not zero, so it adds an element.
+		bs.extend(len(other))
+		copy((*bs)[l:], other[l:])
+		return true
+	}
+	bs.trim()
+	return diff != 0
+}
+
+// AndChanged removes the elements of *bs that are not in other like And
+// and tells if at least one element was removed.
+func (bs *BitSet) AndChanged(other BitSet) bool {
+	n := min(len(*bs), len(other))
+	s, o := (*bs)[:n], other[:n]
+	var diff uint64 // the bits flipped from 1 to 0
+	for i, w := range s {
+		diff |= w &^ o[i]
+		s[i] = w & o[i]
+	}
+	for _, w := range (*bs)[n:] {
+		diff |= w
+	}
+	clear((*bs)[n:])
+	bs.trim()
+	return diff != 0
+}
+
+// AndNotChanged removes the elements of other from *bs like AndNot
+// and tells if at least one element was removed.
+func (bs *BitSet) AndNotChanged(other BitSet) bool {
+	n := min(len(*bs), len(other))
+	s, o := (*bs)[:n], other[:n]
+	var diff uint64 // the bits flipped from 1 to 0
+	for i, w := range s {
+		diff |= w & o[i]
+		s[i] = w &^ o[i]
+	}
+	bs.trim()
+	return diff != 0
+}
+
+// windowMask returns the bits of word i within the inclusive range [m, n],
+// where m>>shift ≤ i ≤ n>>shift.
+func windowMask(i, m, n int) uint64 {
+	start, end := 0, bpw-1
+	if i == m>>shift {
+		start = m & div64rem
+	}
+	if i == n>>shift {
+		end = n & div64rem
+	}
+	return bitMask(start, end)
+}
+
+// OrRange adds the elements e, m ≤ e < n, of other to *bs
+// (no-op if m>=n). The elements of *bs are not changed otherwise.
+func (bs *BitSet) OrRange(other BitSet, m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, min(n>>shift, other.trimmedLen()-1)
+	if low <= high && high >= len(*bs) {
+		bs.resize(high + 1)
+	}
+	for i := low; i <= high; i++ {
+		(*bs)[i] |= other[i] & windowMask(i, m, n)
+	}
+	bs.trim()
+}
+
+// AndRange removes the elements e, m ≤ e < n, of *bs that are not in other
+// (no-op if m>=n). The elements of *bs outside of the range are kept,
+// unlike with And.
+func (bs *BitSet) AndRange(other BitSet, m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, min(n>>shift, len(*bs)-1)
+	for i := low; i <= high; i++ {
+		(*bs)[i] &^= windowMask(i, m, n) &^ other.Word(i)
+	}
+	bs.trim()
+}
+
+// AndNotRange removes the elements e, m ≤ e < n, of other from *bs
+// (no-op if m>=n). The elements of *bs are not changed otherwise.
+func (bs *BitSet) AndNotRange(other BitSet, m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	m = max(0, m)
+	n-- // convert to inclusive range [m, n]
+	low, high := m>>shift, min(n>>shift, len(*bs)-1, len(other)-1)
+	for i := low; i <= high; i++ {
+		(*bs)[i] &^= other[i] & windowMask(i, m, n)
+	}
+	bs.trim()
+}
+
+// Union creates a new set that contains all elements in any of the sets.
+func Union(sets ...BitSet) BitSet {
+	n := 0
+	for _, set := range sets {
+		n = max(n, len(set))
+	}
+	s := make(BitSet, n)
+	for _, set := range sets {
+		for i, w := range set {
+			s[i] |= w
 		}
-		bs.trim()
+	}
+	s.trim()
+	return s
+}
+
+// Intersection creates a new set that consists of the elements present
+// in all of the sets. The intersection of no sets is the empty set.
+func Intersection(sets ...BitSet) BitSet {
+	if len(sets) == 0 {
+		return BitSet{}
+	}
+	n := len(sets[0])
+	for _, set := range sets[1:] {
+		n = min(n, len(set))
+	}
+	if n == 0 {
+		return BitSet{}
+	}
+	s := make(BitSet, n)
+	copy(s, sets[0])
+	for _, set := range sets[1:] {
+		for i := range s {
+			s[i] &= set[i]
+		}
+	}
+	s.trim()
+	return s
+}
+
+// OrAll adds the elements of all others to bs. The final length is
+// computed upfront, so bs is resized at most once and trimmed at the end.
+func (bs *BitSet) OrAll(others ...BitSet) {
+	n := len(*bs)
+	for _, o := range others {
+		n = max(n, len(o))
+	}
+	if n > len(*bs) {
+		bs.resize(n)
+	}
+	for _, o := range others {
+		orWords(*bs, o)
+	}
+	bs.trim()
+}
+
+// AndAll removes the elements of bs that are not in every one of others.
+// bs is trimmed once at the end.
+func (bs *BitSet) AndAll(others ...BitSet) {
+	n := len(*bs)
+	for _, o := range others {
+		n = min(n, len(o))
+	}
+	if n == 0 {
+		bs.Reset()
 		return
 	}
+	bs.resize(n)
+	for _, o := range others {
+		andWords(*bs, o[:n])
+	}
+	bs.trim()
+}
+
+// IntersectionSize returns the number of elements in both a and b
+// without allocating, like And(a, b).Size().
+func IntersectionSize(a, b BitSet) int {
+	n := min(len(a), len(b))
+	size := 0
+	for i := range n {
+		size += bits.OnesCount64(a[i] & b[i])
+	}
+	return size
+}
+
+// UnionSize returns the number of elements in a or b
+// without allocating, like Or(a, b).Size().
+func UnionSize(a, b BitSet) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	size := a[len(b):].Size() // the words of a beyond b
+	for i := range b {
+		size += bits.OnesCount64(a[i] | b[i])
+	}
+	return size
+}
+
+// DifferenceSize returns the number of elements in a but not in b
+// without allocating, like AndNot(a, b).Size().
+func DifferenceSize(a, b BitSet) int {
+	n := min(len(a), len(b))
+	size := a[n:].Size() // the words of a beyond b
+	for i := range n {
+		size += bits.OnesCount64(a[i] &^ b[i])
+	}
+	return size
+}
+
+// SymmetricDifferenceSize returns the number of elements in a or b but
+// not in both without allocating, like Xor(a, b).Size().
+func SymmetricDifferenceSize(a, b BitSet) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	size := a[len(b):].Size() // the words of a beyond b
+	for i := range b {
+		size += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return size
+}
+
+// Jaccard returns the Jaccard index of a and b, that is, the number of
+// elements in both divided by the number of elements in either.
+// Two empty sets are identical, so their index is 1.
+func Jaccard(a, b BitSet) float64 {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	inter, union := 0, a[len(b):].Size()
+	for i := range b {
+		inter += bits.OnesCount64(a[i] & b[i])
+		union += bits.OnesCount64(a[i] | b[i])
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(inter) / float64(union)
+}
+
+// OverlapCoefficient returns the number of elements in both a and b
+// divided by the size of the smaller set. It is 1 if both sets are empty
+// and 0 if only one of them is.
+func OverlapCoefficient(a, b BitSet) float64 {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	inter, sizeA, sizeB := 0, a[len(b):].Size(), 0
+	for i := range b {
+		inter += bits.OnesCount64(a[i] & b[i])
+		sizeA += bits.OnesCount64(a[i])
+		sizeB += bits.OnesCount64(b[i])
+	}
+	switch smaller := min(sizeA, sizeB); {
+	case sizeA == 0 && sizeB == 0:
+		return 1
+	case smaller == 0:
+		return 0
+	default:
+		return float64(inter) / float64(smaller)
+	}
+}
 
-	b := (*bs)[:minLen]
-	o := other[:minLen]
-	for ; len(o) > 7; b, o = b[8:], o[8:] {
-		b[0] &^= o[0]
-		b[1] &^= o[1]
-		b[2] &^= o[2]
-		b[3] &^= o[3]
-		b[4] &^= o[4]
-		b[5] &^= o[5]
-		b[6] &^= o[6]
-		b[7] &^= o[7]
+// reuse makes *bs n words long, reusing its backing array if the capacity
+// allows, and returns the previous length. The words are left uninitialized,
+// so the caller must overwrite all of them and then call clearTail.
+func (bs *BitSet) reuse(n int) int {
+	if cap(*bs) < n {
+		*bs = make(BitSet, n, newCap(n, cap(*bs)))
+		return 0
 	}
-	for i := range o {
-		b[i] &^= o[i]
+	l := len(*bs)
+	*bs = (*bs)[:n]
+	return l
+}
+
+// clearTail zeroes the words left over beyond the length of *bs up to
+// the previous length l, and trims *bs.
+func (bs *BitSet) clearTail(l int) {
+	if l > len(*bs) {
+		clear((*bs)[len(*bs):l])
 	}
 	bs.trim()
 }
 
-// writeRange appends either "", "a", "a b" or "a..b" to buf.
-func writeRange(buf *strings.Builder, a, b int) {
+// AndTo replaces the contents of *dst with the elements in both s1 and s2,
+// reusing the capacity of *dst. The destination may be s1 or s2 itself,
+// since every word of the result only depends on the words at the same index.
+func AndTo(dst *BitSet, s1, s2 BitSet) {
+	n := min(len(s1), len(s2))
+	l := dst.reuse(n)
+	d := *dst
+	for i := 0; i < n; i++ {
+		d[i] = s1[i] & s2[i]
+	}
+	dst.clearTail(l)
+}
+
+// OrTo replaces the contents of *dst with the elements in s1 or s2,
+// reusing the capacity of *dst. The destination may be s1 or s2 itself.
+func OrTo(dst *BitSet, s1, s2 BitSet) {
+	if len(s1) < len(s2) {
+		s1, s2 = s2, s1 // swap to make s1 the longer set
+	}
+	l := dst.reuse(len(s1))
+	d := *dst
+	for i := range s2 {
+		d[i] = s1[i] | s2[i]
+	}
+	copy(d[len(s2):], s1[len(s2):])
+	dst.clearTail(l)
+}
+
+// XorTo replaces the contents of *dst with the elements in s1 or s2 but not
+// both, reusing the capacity of *dst. The destination may be s1 or s2 itself.
+func XorTo(dst *BitSet, s1, s2 BitSet) {
+	if len(s1) < len(s2) {
+		s1, s2 = s2, s1 // swap to make s1 the longer set
+	}
+	l := dst.reuse(len(s1))
+	d := *dst
+	for i := range s2 {
+		d[i] = s1[i] ^ s2[i]
+	}
+	copy(d[len(s2):], s1[len(s2):])
+	dst.clearTail(l)
+}
+
+// AndNotTo replaces the contents of *dst with the elements in s1 but not
+// in s2, reusing the capacity of *dst. The destination may be s1 or s2 itself.
+func AndNotTo(dst *BitSet, s1, s2 BitSet) {
+	n := min(len(s1), len(s2))
+	l := dst.reuse(len(s1))
+	d := *dst
+	for i := 0; i < n; i++ {
+		d[i] = s1[i] &^ s2[i]
+	}
+	copy(d[n:], s1[n:])
+	dst.clearTail(l)
+}
+
+// appendRange appends either "", "a", "a b" or "a..b" to buf.
+func appendRange(buf []byte, a, b int) []byte {
 	switch {
 	case a > b:
-		return
+		return buf
 	case a == b:
-		fmt.Fprintf(buf, "%d", a)
+		return strconv.AppendInt(buf, int64(a), 10)
 	case a+1 == b:
-		fmt.Fprintf(buf, "%d %d", a, b)
+		buf = strconv.AppendInt(buf, int64(a), 10)
+		buf = append(buf, ' ')
+		return strconv.AppendInt(buf, int64(b), 10)
 	default:
-		fmt.Fprintf(buf, "%d..%d", a, b)
+		buf = strconv.AppendInt(buf, int64(a), 10)
+		buf = append(buf, ".."...)
+		return strconv.AppendInt(buf, int64(b), 10)
 	}
 }
 
@@ -600,28 +2300,183 @@ func writeRange(buf *strings.Builder, a, b int) {
 //
 // Example: {0 2 4..7 9 11 13 15}
 func (bs BitSet) String() string {
-	buf := new(strings.Builder)
-	buf.WriteByte('{')
-	a, b := -1, -2
-	first := true
-	bs.Visit(func(n int) bool {
-		if n == b+1 {
-			b++
-			return false
+	return bs.StringMaxRuns(-1)
+}
+
+// AppendString appends the String representation of the set to buf
+// and returns the extended buffer.
+func (bs BitSet) AppendString(buf []byte) []byte {
+	return bs.appendString(buf, -1)
+}
+
+// StringMaxRuns returns a string representation of the set like String,
+// but lists at most maxRuns runs of consecutive elements followed by
+// the number of elements left out. A negative maxRuns lists all runs.
+//
+// Example: {0..9 20 ... +381 more}
+func (bs BitSet) StringMaxRuns(maxRuns int) string {
+	runs := bs.NumRanges()
+	if maxRuns >= 0 {
+		runs = min(runs, maxRuns)
+	}
+	// Each run takes at most two numbers no longer than Max() and a separator.
+	var digits [20]byte
+	width := len(strconv.AppendInt(digits[:0], int64(bs.Max()), 10))
+	buf := make([]byte, 0, runs*(2*width+3)+2)
+	return string(bs.appendString(buf, maxRuns))
+}
+
+// appendString appends the representation of the set with at most maxRuns
+// runs to buf, or all runs if maxRuns is negative.
+func (bs BitSet) appendString(buf []byte, maxRuns int) []byte {
+	buf = append(buf, '{')
+	shown := 0
+	for a, b := range bs.Ranges() {
+		if shown > 0 {
+			buf = append(buf, ' ')
 		}
-		if first && a <= b {
-			first = false
-		} else if a <= b {
-			buf.WriteByte(' ')
+		if maxRuns == 0 {
+			buf = append(buf, "... +"...)
+			buf = strconv.AppendInt(buf, int64(bs.Size()-shown), 10)
+			buf = append(buf, " more"...)
+			break
 		}
-		writeRange(buf, a, b)
-		a, b = n, n
-		return false
-	})
-	if !first && a <= b {
-		buf.WriteByte(' ')
+		maxRuns--
+		buf = appendRange(buf, a, b)
+		shown += b - a + 1
+	}
+	return append(buf, '}')
+}
+
+// Summary returns a one-line description of bs for logs, computed in
+// one pass over the words. The density is the share of the integers
+// from Min() to Max() that are in bs.
+//
+// Example: BitSet{size=48213 min=3 max=991230 ranges=1042 density=4.9%}
+func (bs BitSet) Summary() string {
+	size, ranges, first, last := 0, 0, -1, -1
+	var carry uint64 // the highest bit of the previous word
+	for i, w := range bs {
+		if w == 0 {
+			carry = 0
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+		size += bits.OnesCount64(w)
+		ranges += bits.OnesCount64(w &^ (w<<1 | carry))
+		carry = w >> (bpw - 1)
+	}
+	if size == 0 {
+		return "BitSet{empty}"
+	}
+	minElem := first<<shift + bits.TrailingZeros64(bs[first])
+	maxElem := last<<shift + bpw - 1 - bits.LeadingZeros64(bs[last])
+	density := 100 * float64(size) / float64(maxElem-minElem+1)
+	return fmt.Sprintf("BitSet{size=%d min=%d max=%d ranges=%d density=%.1f%%}",
+		size, minElem, maxElem, ranges, density)
+}
+
+// Format implements the fmt.Formatter interface. The supported verbs are:
+//
+//	%v, %s  the String representation, e.g. {0 2 4..7}
+//	%#v     Go syntax that rebuilds the set as returned by GoString
+//	%d      the elements without range compression, e.g. 0 2 4 5 6 7
+//	%b      the bits from the least significant one up to Max(), e.g. 1010111
+//	%x, %X  the words in hexadecimal, e.g. [f5]
+func (bs BitSet) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		if verb == 'v' && f.Flag('#') {
+			fmt.Fprint(f, bs.GoString())
+			return
+		}
+		fmt.Fprintf(f, fmt.FormatString(f, 's'), bs.String())
+	case 'd':
+		format, sep := fmt.FormatString(f, verb), ""
+		for n := range bs.All() {
+			fmt.Fprint(f, sep)
+			fmt.Fprintf(f, format, n)
+			sep = " "
+		}
+	case 'b':
+		fmt.Fprintf(f, fmt.FormatString(f, 's'), bs.BitString())
+	case 'x', 'X':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), []uint64(bs))
+	default:
+		fmt.Fprintf(f, "%%!%c(bitset.BitSet=%s)", verb, bs.String())
+	}
+}
+
+const (
+	goStringMaxRuns  = 64 // runs listed by GoString before eliding the rest
+	goStringMinRange = 8  // runs at least this long are listed as FromRange calls
+)
+
+// GoString implements the fmt.GoStringer interface and returns Go source
+// that rebuilds the set, e.g. bitset.New(1, 2, 128), or for sets with long
+// runs bitset.Union(bitset.New(1, 2), bitset.FromRange(64, 576)). Only the
+// first 64 runs are listed; the number of elided elements follows them
+// in a comment.
+func (bs BitSet) GoString() string {
+	var elems []int
+	var ranges [][2]int
+	shown, runs := 0, 0
+	for a, b := range bs.Ranges() {
+		if runs == goStringMaxRuns {
+			break
+		}
+		runs++
+		shown += b - a + 1
+		if b-a+1 >= goStringMinRange {
+			ranges = append(ranges, [2]int{a, b + 1})
+			continue
+		}
+		for n := a; n <= b; n++ {
+			elems = append(elems, n)
+		}
+	}
+	var elided []byte
+	if rest := bs.Size() - shown; rest > 0 {
+		elided = append(elided, " /* +"...)
+		elided = strconv.AppendInt(elided, int64(rest), 10)
+		elided = append(elided, " more elements */"...)
+	}
+
+	appendNew := func(buf []byte) []byte {
+		buf = append(buf, "bitset.New("...)
+		for i, n := range elems {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = strconv.AppendInt(buf, int64(n), 10)
+		}
+		return append(buf, ')')
 	}
-	writeRange(buf, a, b)
-	buf.WriteByte('}')
-	return buf.String()
+	switch {
+	case len(ranges) == 0:
+		buf := appendNew(nil)
+		buf = append(buf[:len(buf)-1], elided...)
+		return string(append(buf, ')'))
+	case len(ranges) == 1 && len(elems) == 0 && elided == nil:
+		return "bitset.FromRange(" + strconv.Itoa(ranges[0][0]) + ", " + strconv.Itoa(ranges[0][1]) + ")"
+	}
+	buf := []byte("bitset.Union(")
+	if len(elems) > 0 {
+		buf = append(appendNew(buf), ", "...)
+	}
+	for i, r := range ranges {
+		if i > 0 {
+			buf = append(buf, ", "...)
+		}
+		buf = append(buf, "bitset.FromRange("...)
+		buf = strconv.AppendInt(buf, int64(r[0]), 10)
+		buf = append(buf, ", "...)
+		buf = strconv.AppendInt(buf, int64(r[1]), 10)
+		buf = append(buf, ')')
+	}
+	buf = append(buf, elided...)
+	return string(append(buf, ')'))
 }
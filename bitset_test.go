@@ -1,8 +1,19 @@
 package bitset
 
 import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
 	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/require"
 )
@@ -32,6 +43,100 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewFromSorted(t *testing.T) {
+	tests := []struct {
+		name   string
+		elems  []int
+		expect string
+	}{
+		{"empty", nil, "{}"},
+		{"all negatives", []int{-10, -2, -1}, "{}"},
+		{"single elem", []int{1}, "{1}"},
+		{"duplicates", []int{1, 1, 64, 64, 64}, "{1 64}"},
+		{"leading negatives", []int{-5, -1, 0, 2}, "{0 2}"},
+		{"word boundaries", []int{0, 63, 64, 127, 128}, "{0 63 64 127 128}"},
+		{"sparse", []int{100, 200, 300, 1000}, "{100 200 300 1000}"},
+		{"out of order", []int{300, 5, 200, 70, 400}, "{5 70 200 300 400}"},
+		{"out of order past last", []int{5, 500, 70}, "{5 70 500}"},
+		{"negative last", []int{5, 70, -1}, "{5 70}"},
+		{"negative in the middle", []int{5, -1, 70}, "{5 70}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := NewFromSorted(tt.elems)
+			require.Equal(t, tt.expect, bs.String())
+			require.Equal(t, New(tt.elems...), bs)
+		})
+	}
+}
+
+func TestNewCapped(t *testing.T) {
+	tests := []struct {
+		name   string
+		max    int
+		elems  []int
+		expect string
+		err    string
+	}{
+		{"empty", 10, nil, "{}", ""},
+		{"all negatives", 10, []int{-1, -2}, "{}", ""},
+		{"within", 100, []int{1, 64, 100}, "{1 64 100}", ""},
+		{"mixed sign", 100, []int{1, -2, 2}, "{1 2}", ""},
+		{"exceeds", 100, []int{1, 1_000_000_000, 2}, "", "bitset: element 1000000000 exceeds the maximum of 100"},
+		{"negative maximum", -1, []int{0}, "", "bitset: element 0 exceeds the maximum of -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, err := NewCapped(tt.max, tt.elems...)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Nil(t, bs)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, bs.String())
+			require.True(t, bs.Equal(New(tt.elems...)))
+		})
+	}
+}
+
+func TestFromRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		m, n   int
+		expect string
+	}{
+		{"empty range", 0, 0, "{}"},
+		{"reversed range", 2, 1, "{}"},
+		{"neg range", -2, -1, "{}"},
+		{"part neg", -1, 2, "{0 1}"},
+		{"simple range", 1, 10, "{1..9}"},
+		{"word boundary end", 0, 64, "{0..63}"},
+		{"word boundary start", 64, 66, "{64 65}"},
+		{"exact words", 64, 192, "{64..191}"},
+		{"large", 1, 1000, "{1..999}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromRange(tt.m, tt.n)
+			require.Equal(t, tt.expect, got.String())
+
+			expect := New()
+			expect.AddRange(tt.m, tt.n)
+			require.True(t, got.Equal(expect))
+		})
+	}
+
+	t.Run("universe", func(t *testing.T) {
+		require.Equal(t, "{}", Universe(0).String())
+		require.Equal(t, "{0..575}", Universe(576).String())
+		require.Len(t, Universe(576), 9)
+	})
+}
+
 func TestBitSet_Contains(t *testing.T) {
 	bsEmpty := New()
 	bsSet := New(0, 1, 2, 65, 100)
@@ -60,6 +165,91 @@ func TestBitSet_Contains(t *testing.T) {
 	}
 }
 
+// TestBitSet_ContainsNoAlloc guards the hot path of Contains and Next.
+// Their bounds checks are eliminated by the compiler, which can be verified
+// with:
+//
+//	go build -gcflags=-d=ssa/check_bce/debug=1 . 2>&1 | grep bitset.go
+//
+// No line of Contains or Next must be reported. Contains must also stay
+// inlinable, see: go build -gcflags=-m . 2>&1 | grep Contains
+func TestBitSet_ContainsNoAlloc(t *testing.T) {
+	bs := New(0, 5, 64, 1000)
+	res := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; b.Loop(); i++ {
+			bs.Contains(i%2000 - 500)
+			bs.Next(i%2000 - 500)
+		}
+	})
+	require.Zero(t, res.AllocsPerOp())
+	require.False(t, bs.Contains(math.MinInt))
+	require.False(t, bs.Contains(math.MaxInt))
+	require.Equal(t, 0, bs.Next(math.MinInt))
+	require.Equal(t, -1, bs.Next(math.MaxInt))
+	require.Equal(t, 5, New(5).Next(-1))
+}
+
+func TestBitSet_ContainsAllAny(t *testing.T) {
+	bs := New(1, 63, 64, 200)
+	tests := []struct {
+		name string
+		n    []int
+		all  bool
+		any  bool
+	}{
+		{"none given", nil, true, false},
+		{"all present", []int{64, 1, 200}, true, true},
+		{"some present", []int{1, 2, 64}, false, true},
+		{"none present", []int{0, 65, 1000}, false, false},
+		{"negative", []int{1, -1}, false, true},
+		{"only negative", []int{-64}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.all, bs.ContainsAll(tt.n...))
+			require.Equal(t, tt.any, bs.ContainsAny(tt.n...))
+		})
+	}
+}
+
+func TestBitSet_ContainsRange(t *testing.T) {
+	bs := FromRange(10, 200)
+	tests := []struct {
+		name   string
+		bs     BitSet
+		m, n   int
+		expect bool
+	}{
+		{"empty range", bs, 5, 5, true},
+		{"inverted range", bs, 300, 5, true},
+		{"empty set", New(), 0, 1, false},
+		{"negative", FromRange(0, 10), -1, 5, false},
+		{"inside word", bs, 20, 30, true},
+		{"exact", bs, 10, 200, true},
+		{"below start", bs, 9, 20, false},
+		{"past end", bs, 100, 201, false},
+		{"beyond words", bs, 150, 1000, false},
+		{"word boundary 63", FromRange(0, 64), 0, 64, true},
+		{"word boundary 64", FromRange(0, 64), 0, 65, false},
+		{"word boundary 128", FromRange(64, 128), 64, 128, true},
+		{"word boundary 63 missing", FromRange(64, 128), 63, 128, false},
+		{"full middle word", FromRange(60, 200), 60, 200, true},
+		{"hole in middle word", New(100).Complement(300), 60, 200, false},
+		{"hole at high end", New(191).Complement(300), 60, 192, false},
+		{"hole at low end", New(60).Complement(300), 60, 192, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs.ContainsRange(tt.m, tt.n))
+			expect := tt.m >= tt.n || tt.m >= 0 && tt.bs.CountRange(tt.m, tt.n) == tt.n-tt.m
+			require.Equal(t, expect, tt.expect)
+		})
+	}
+}
+
 func TestBitSet_Equal(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -74,6 +264,10 @@ func TestBitSet_Equal(t *testing.T) {
 		{"identical bigger", New(1, 2, 65), New(1, 2, 65), true},
 		{"both large same", New(100, 200, 300), New(100, 200, 300), true},
 		{"both large diff", New(100, 200, 300), New(200, 300, 400), false},
+		{"untrimmed equal", BitSet{0b10, 0}, New(1), true},
+		{"untrimmed both", BitSet{0b10, 0, 0}, BitSet{0b10, 0}, true},
+		{"untrimmed empty", BitSet{0, 0}, New(), true},
+		{"untrimmed different", BitSet{0b10, 0}, New(2), false},
 	}
 
 	for _, tt := range tests {
@@ -84,6 +278,124 @@ func TestBitSet_Equal(t *testing.T) {
 	}
 }
 
+func TestBitSet_Cmp(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs1    BitSet
+		bs2    BitSet
+		expect int
+	}{
+		{"both empty", New(), New(), 0},
+		{"empty and non empty", New(), New(0), -1},
+		{"identical", New(1, 2, 300), New(1, 2, 300), 0},
+		{"differ in low bit", New(0, 2), New(1, 2), -1},
+		{"differ in low bit reversed", New(1, 2), New(0, 2), 1},
+		{"differ in high word", New(1, 300), New(1, 200), 1},
+		{"high bit beats low bits", New(64), New(0, 1, 2, 63), 1},
+		{"prefix", New(1, 2), New(1, 2, 300), -1},
+		{"prefix reversed", New(1, 2, 300), New(1, 2), 1},
+		{"trailing zero words", BitSet{0b10, 0, 0}, BitSet{0b10}, 0},
+		{"trailing zero words smaller", BitSet{0b01, 0, 0}, BitSet{0b10}, -1},
+		{"all zero words", BitSet{0, 0}, New(), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs1.Cmp(tt.bs2))
+			require.Equal(t, -tt.expect, tt.bs2.Cmp(tt.bs1))
+		})
+	}
+
+	t.Run("sorted", func(t *testing.T) {
+		sets := []BitSet{New(64), New(0, 1), New(), New(1), New(0), New(0, 64)}
+		slices.SortFunc(sets, BitSet.Cmp)
+		require.Equal(t, "[{} {0} {1} {0 1} {64} {0 64}]", fmt.Sprint(sets))
+	})
+}
+
+func TestEqualRange(t *testing.T) {
+	base := FromRange(10, 300)
+	base.Add(1000)
+	with := func(n ...int) BitSet {
+		b := base.Copy()
+		for _, e := range n {
+			b.Flip(e)
+		}
+		return b
+	}
+	tests := []struct {
+		name   string
+		a, b   BitSet
+		m, n   int
+		expect bool
+	}{
+		{"empty range", New(1), New(2), 5, 5, true},
+		{"neg range", New(1), New(2), -5, 0, true},
+		{"both empty", New(), New(), 0, 100, true},
+		{"equal", base, base.Copy(), 0, 2000, true},
+		{"differ outside", base, with(5, 70, 1000), 71, 900, true},
+		{"differ below and above", base, with(99, 200), 100, 200, true},
+		{"differ at m", base, with(100), 100, 200, false},
+		{"differ at n-1", base, with(199), 100, 200, false},
+		{"differ at word boundary", base, with(128), 100, 200, false},
+		{"differ at word end", base, with(127), 100, 200, false},
+		{"aligned window", base, with(63, 128), 64, 128, true},
+		{"one shorter than window", New(1, 5), New(1, 5, 100_000), 0, 1000, true},
+		{"one shorter differs", New(1, 5), New(1, 5, 100_000), 0, 200_000, false},
+		{"both shorter", New(1), New(1), 1000, 2000, true},
+		{"untrimmed", BitSet{0b10, 0, 0}, New(1), 0, 1000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, EqualRange(tt.a, tt.b, tt.m, tt.n))
+			require.Equal(t, tt.expect, EqualRange(tt.b, tt.a, tt.m, tt.n))
+			require.Equal(t, tt.expect, tt.a.Extract(tt.m, tt.n).Equal(tt.b.Extract(tt.m, tt.n)))
+		})
+	}
+}
+
+func TestFirstLastDiff(t *testing.T) {
+	base := New(1, 5, 63, 64, 100, 500)
+	with := func(n int) BitSet {
+		b := base.Copy()
+		b.Flip(n)
+		return b
+	}
+	tests := []struct {
+		name        string
+		a, b        BitSet
+		first, last int
+	}{
+		{"both empty", New(), New(), -1, -1},
+		{"equal", base, base.Copy(), -1, -1},
+		{"equal untrimmed", append(base.Copy(), 0, 0), base, -1, -1},
+		{"one empty", New(), base, 1, 500},
+		{"diff at 0", base, with(0), 0, 0},
+		{"diff at 63", base, with(63), 63, 63},
+		{"diff at 64", base, with(64), 64, 64},
+		{"diff deep in a high word", base, with(100_000), 100_000, 100_000},
+		{"diff deep untrimmed", append(with(100_000), 0), base, 100_000, 100_000},
+		{"several", New(1, 2, 3, 200), New(1, 3, 70, 200), 2, 70},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := Xor(tt.a, tt.b)
+			require.Equal(t, tt.first, FirstDiff(tt.a, tt.b))
+			require.Equal(t, tt.first, FirstDiff(tt.b, tt.a))
+			require.Equal(t, x.Min(), FirstDiff(tt.a, tt.b))
+			require.Equal(t, tt.last, LastDiff(tt.a, tt.b))
+			require.Equal(t, tt.last, LastDiff(tt.b, tt.a))
+			require.Equal(t, x.Max(), LastDiff(tt.a, tt.b))
+			require.Zero(t, testing.AllocsPerRun(10, func() {
+				FirstDiff(tt.a, tt.b)
+				LastDiff(tt.a, tt.b)
+			}))
+		})
+	}
+}
+
 func TestBitSet_Subset(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -109,6 +421,104 @@ func TestBitSet_Subset(t *testing.T) {
 	}
 }
 
+func TestBitSet_ProperSubsetSuperset(t *testing.T) {
+	tests := []struct {
+		name           string
+		bs1            BitSet
+		bs2            BitSet
+		subset         bool
+		properSubset   bool
+		superset       bool
+		properSuperset bool
+	}{
+		{"empty vs empty", New(), New(), true, false, true, false},
+		{"empty vs non empty", New(), New(1), true, true, false, false},
+		{"non empty vs empty", New(1), New(), false, false, true, true},
+		{"identical", New(1, 2, 3), New(1, 2, 3), true, false, true, false},
+		{"proper subset", New(1, 2), New(1, 2, 3), true, true, false, false},
+		{"not related", New(1, 4), New(1, 2, 3), false, false, false, false},
+		{"differ only in high word", New(1, 2), New(1, 2, 300), true, true, false, false},
+		{"superset in high word", New(1, 2, 300), New(1, 2), false, false, true, true},
+		{"identical trailing zero words", BitSet{0b110, 0, 0}, BitSet{0b110}, true, false, true, false},
+		{"proper subset trailing zero words", BitSet{0b010, 0, 0}, BitSet{0b110}, true, true, false, false},
+		{"proper superset trailing zero words", BitSet{0b110}, BitSet{0b010, 0, 0}, false, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.subset, tt.bs1.Subset(tt.bs2))
+			require.Equal(t, tt.properSubset, tt.bs1.ProperSubset(tt.bs2))
+			require.Equal(t, tt.superset, tt.bs1.Superset(tt.bs2))
+			require.Equal(t, tt.properSuperset, tt.bs1.ProperSuperset(tt.bs2))
+			require.Zero(t, testing.AllocsPerRun(10, func() {
+				tt.bs1.ProperSubset(tt.bs2)
+				tt.bs1.ProperSuperset(tt.bs2)
+			}))
+		})
+	}
+}
+
+func TestBitSet_Intersects(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs1    BitSet
+		bs2    BitSet
+		expect bool
+	}{
+		{"both empty", New(), New(), false},
+		{"empty and non empty", New(), New(1), false},
+		{"non empty and empty", New(1), New(), false},
+		{"same", New(1), New(1), true},
+		{"no overlap", New(1, 2), New(3, 4), false},
+		{"partial overlap", New(1, 2), New(2, 3), true},
+		{"word boundary 63 64", New(63), New(64), false},
+		{"word boundary 64", New(63, 64), New(64), true},
+		{"large overlap", New(100, 200), New(200, 300), true},
+		{"large no overlap", New(100, 200), New(300, 400), false},
+		{"trailing zero words", BitSet{0b10, 0, 0}, BitSet{0b11, 0}, true},
+		{"trailing zero words no overlap", BitSet{0b10, 0, 0}, BitSet{0b01}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs1.Intersects(tt.bs2))
+			require.Equal(t, tt.expect, tt.bs2.Intersects(tt.bs1))
+			require.Equal(t, tt.expect, !And(tt.bs1, tt.bs2).Empty())
+		})
+	}
+}
+
+func TestBitSet_Disjoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs1    BitSet
+		bs2    BitSet
+		expect bool
+	}{
+		{"both empty", New(), New(), true},
+		{"empty and non empty", New(), New(1), true},
+		{"non empty and empty", New(1), New(), true},
+		{"same", New(1), New(1), false},
+		{"no overlap", New(1, 2), New(3, 4), true},
+		{"partial overlap", New(1, 2), New(2, 3), false},
+		{"word boundary 63 64", New(63), New(64), true},
+		{"word boundary overlap 63", New(63), New(63, 64), false},
+		{"word boundary overlap 64", New(63, 64), New(64, 65), false},
+		{"large overlap", New(100, 200), New(200, 300), false},
+		{"large no overlap", New(100, 200), New(300, 400), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs1.Disjoint(tt.bs2))
+			require.Equal(t, tt.expect, tt.bs2.Disjoint(tt.bs1))
+			require.Zero(t, testing.AllocsPerRun(10, func() {
+				tt.bs1.Disjoint(tt.bs2)
+			}))
+		})
+	}
+}
+
 func TestBitSet_Max(t *testing.T) {
 	t.Run("negative on empty", func(t *testing.T) {
 		empty := New()
@@ -133,6 +543,25 @@ func TestBitSet_Max(t *testing.T) {
 	}
 }
 
+func TestBitSet_Min(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect int
+	}{
+		{"empty", New(), -1},
+		{"single 0", New(0), 0},
+		{"single 65", New(65), 65},
+		{"several", New(64, 100, 1, 2), 1},
+		{"large", New(100, 200, 300), 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs.Min())
+		})
+	}
+}
+
 func TestBitSet_Size(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -175,6 +604,117 @@ func TestBitSet_Size(t *testing.T) {
 	}
 }
 
+func TestBitSet_CountRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		m, n   int
+		before []int
+		expect int
+	}{
+		{"empty set", 0, 100, nil, 0},
+		{"empty range", 0, 0, []int{0, 1, 2}, 0},
+		{"empty range neg", 2, 1, []int{1, 2, 3}, 0},
+		{"neg range", -2, -1, []int{0, 1}, 0},
+		{"part neg", -1, 1, []int{0, 1}, 1},
+		{"same word", 1, 10, []int{0, 1, 5, 9, 10}, 3},
+		{"extend 64", 64, 66, []int{63, 64, 65, 66}, 2},
+		{"cross one boundary", 60, 70, []int{59, 60, 63, 64, 69, 70}, 4},
+		{"cross many words", 1, 1000, []int{0, 1, 100, 500, 999, 1000}, 4},
+		{"past last word", 50, 10000, []int{49, 50, 100, 200}, 3},
+		{"start past last word", 1000, 2000, []int{1, 100}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			require.Equal(t, tt.expect, bs.CountRange(tt.m, tt.n))
+		})
+	}
+
+	t.Run("range built", func(t *testing.T) {
+		bs := New()
+		bs.AddRange(0, 576)
+		require.Equal(t, 576, bs.CountRange(0, 576))
+		require.Equal(t, 576, bs.CountRange(-10, 1000))
+		require.Equal(t, 64, bs.CountRange(64, 128))
+		require.Equal(t, 2, bs.CountRange(63, 65))
+		require.Equal(t, 0, bs.CountRange(576, 1000))
+	})
+}
+
+func TestBitSet_RankSelect(t *testing.T) {
+	bs := New(0, 2, 63, 64, 100, 300)
+	tests := []struct {
+		name    string
+		bs      BitSet
+		n       int
+		rank    int
+		k       int
+		selectN int
+	}{
+		{"empty", New(), 10, 0, 0, -1},
+		{"empty neg", New(), -1, 0, -1, -1},
+		{"neg", bs, -1, 0, -1, -1},
+		{"zero", bs, 0, 0, 0, 0},
+		{"one", bs, 1, 1, 1, 2},
+		{"on 63", bs, 63, 2, 2, 63},
+		{"on 64", bs, 64, 3, 3, 64},
+		{"on 65", bs, 65, 4, 4, 100},
+		{"on 300", bs, 300, 5, 5, 300},
+		{"after max", bs, 1000, 6, 6, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.rank, tt.bs.Rank(tt.n))
+			require.Equal(t, tt.selectN, tt.bs.Select(tt.k))
+		})
+	}
+
+	t.Run("inverse on random set", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(1, 2))
+		bs := New()
+		for range 1000 {
+			bs.Add(r.IntN(10000))
+		}
+		k := 0
+		bs.VisitAll(func(n int) {
+			require.Equal(t, k, bs.Rank(n))
+			require.Equal(t, n, bs.Select(bs.Rank(n)))
+			k++
+		})
+		require.Equal(t, bs.Size(), bs.Rank(bs.Max()+1))
+		require.Equal(t, -1, bs.Select(bs.Size()))
+	})
+}
+
+func TestBitSet_Quantile(t *testing.T) {
+	require.Equal(t, -1, New().Quantile(0.5))
+	require.Equal(t, -1, New().Median())
+	require.Equal(t, -1, New(1).Quantile(-0.1))
+	require.Equal(t, -1, New(1).Quantile(1.1))
+	require.Equal(t, -1, New(1).Quantile(math.NaN()))
+	require.Equal(t, 5, New(5).Median())
+	require.Equal(t, 64, New(1, 64, 100).Median())
+	require.Equal(t, 100, New(1, 64, 100, 200).Median())
+	require.Equal(t, 64, New(1, 64, 100, 200).Quantile(0.5))
+
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 20 {
+		bs := New()
+		for range 1 + r.IntN(500) {
+			bs.Add(r.IntN(5000))
+		}
+		elems := bs.ToSlice()
+		require.Equal(t, bs.Min(), bs.Quantile(0))
+		require.Equal(t, bs.Max(), bs.Quantile(1))
+		require.Equal(t, elems[len(elems)/2], bs.Median())
+		for _, q := range []float64{0.01, 0.25, 0.5, 0.9, 0.99, r.Float64()} {
+			require.Equal(t, elems[int(q*float64(len(elems)-1))], bs.Quantile(q), "q=%v", q)
+		}
+	}
+}
+
 func TestBitSet_Empty(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -202,135 +742,1259 @@ func TestBitSet_Empty(t *testing.T) {
 	}
 }
 
+func TestBitSet_Untrimmed(t *testing.T) {
+	sets := []BitSet{
+		New(),
+		New(1),
+		New(1, 64),
+		New(0, 63, 64, 200),
+		New(5, 300),
+	}
+	pad := func(bs BitSet, n int) BitSet {
+		return append(bs.Copy(), make(BitSet, n)...)
+	}
+
+	for _, a := range sets {
+		for _, b := range sets {
+			ua, ub := pad(a, 2), pad(b, 1)
+			t.Run(a.String()+" "+b.String(), func(t *testing.T) {
+				require.Equal(t, a.Equal(b), ua.Equal(ub))
+				require.Equal(t, a.Equal(b), ua.Equal(b))
+				require.Equal(t, a.Cmp(b), ua.Cmp(ub))
+				require.Equal(t, a.Subset(b), ua.Subset(ub))
+				require.Equal(t, a.ProperSubset(b), ua.ProperSubset(ub))
+				require.Equal(t, a.Superset(b), ua.Superset(ub))
+				require.Equal(t, a.ProperSuperset(b), ua.ProperSuperset(ub))
+				require.Equal(t, a.Intersects(b), ua.Intersects(ub))
+				require.Equal(t, a.Disjoint(b), ua.Disjoint(ub))
+			})
+		}
+
+		ua := pad(a, 3)
+		require.True(t, ua.Equal(a))
+		require.Equal(t, a.Max(), ua.Max())
+		require.Equal(t, a.Min(), ua.Min())
+		require.Equal(t, a.Size(), ua.Size())
+		require.Equal(t, a.Empty(), ua.Empty())
+	}
+}
+
+func TestBitSet_DeleteNoTrim(t *testing.T) {
+	r := rand.New(rand.NewPCG(9, 10))
+	for range 20 {
+		var bs BitSet
+		for range 50 {
+			bs.Add(r.IntN(1000))
+		}
+		lazy := bs.Copy()
+		for range 100 {
+			if r.IntN(4) == 0 {
+				m := r.IntN(1100) - 50
+				n := m + r.IntN(200)
+				bs.DeleteRange(m, n)
+				lazy.DeleteRangeNoTrim(m, n)
+			} else {
+				n := bs.Max()
+				if r.IntN(2) == 0 {
+					n = r.IntN(1100) - 50
+				}
+				bs.Delete(n)
+				lazy.DeleteNoTrim(n)
+			}
+
+			require.True(t, bs.Equal(lazy))
+			require.True(t, lazy.Equal(bs))
+			require.Equal(t, bs.Max(), lazy.Max())
+			require.Equal(t, bs.Min(), lazy.Min())
+			require.Equal(t, bs.Size(), lazy.Size())
+			require.Equal(t, bs.Empty(), lazy.Empty())
+			require.Equal(t, bs.String(), lazy.String())
+			require.GreaterOrEqual(t, len(lazy), len(bs))
+		}
+
+		lazy.Trim()
+		require.Equal(t, bs, lazy)
+		require.NoError(t, lazy.Validate())
+	}
+}
+
 func TestBitSet_NextPrev(t *testing.T) {
 	bs := New(0, 2, 63, 64, 100, 300)
 	tests := []struct {
-		name  string
-		bs    BitSet
-		m     int
-		nextN int
-		prevN int
+		name   string
+		bs     BitSet
+		m      int
+		nextN  int
+		prevN  int
+		ceilN  int
+		floorN int
 	}{
-		{"empty", New(), 1, -1, -1},
-		{"empty zero", New(), 0, -1, -1},
-		{"empty neg", New(), -1, -1, -1},
+		{"empty", New(), 1, -1, -1, -1, -1},
+		{"empty zero", New(), 0, -1, -1, -1, -1},
+		{"empty neg", New(), -1, -1, -1, -1, -1},
 
-		{"set neg", bs, -1, 0, -1},
-		{"set before 0", bs, 0, 2, -1},
-		{"set between 0 and 2", bs, 1, 2, 0},
-		{"set on 2", bs, 2, 63, 0},
-		{"set between 2 and 63", bs, 50, 63, 2},
-		{"set on 63", bs, 63, 64, 2},
-		{"set on 64", bs, 64, 100, 63},
-		{"set between 64 and 100", bs, 70, 100, 64},
-		{"set on 100", bs, 100, 300, 64},
-		{"set between 100 and 300", bs, 200, 300, 100},
-		{"set on 300", bs, 300, -1, 100},
-		{"past 300", bs, 400, -1, 300},
+		{"set neg", bs, -1, 0, -1, 0, -1},
+		{"set before 0", bs, 0, 2, -1, 0, 0},
+		{"set between 0 and 2", bs, 1, 2, 0, 2, 0},
+		{"set on 2", bs, 2, 63, 0, 2, 2},
+		{"set between 2 and 63", bs, 50, 63, 2, 63, 2},
+		{"set on 63", bs, 63, 64, 2, 63, 63},
+		{"set on 64", bs, 64, 100, 63, 64, 64},
+		{"set between 64 and 100", bs, 70, 100, 64, 100, 64},
+		{"set on 100", bs, 100, 300, 64, 100, 100},
+		{"set between 100 and 300", bs, 200, 300, 100, 300, 100},
+		{"set on 300", bs, 300, -1, 100, 300, 300},
+		{"past 300", bs, 400, -1, 300, -1, 300},
+		{"far past 300", bs, math.MaxInt, -1, 300, -1, 300},
+		{"min int", bs, math.MinInt, 0, -1, 0, -1},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			n := tt.bs.Next(tt.m)
-			p := tt.bs.Prev(tt.m)
-			require.Equal(t, tt.nextN, n)
-			require.Equal(t, tt.prevN, p)
+			require.Equal(t, tt.nextN, tt.bs.Next(tt.m))
+			require.Equal(t, tt.prevN, tt.bs.Prev(tt.m))
+			require.Equal(t, tt.ceilN, tt.bs.Ceil(tt.m))
+			require.Equal(t, tt.floorN, tt.bs.Floor(tt.m))
 		})
 	}
 }
 
-func TestBitSet_Visit(t *testing.T) {
+func TestBitSet_NextPrevUntrimmed(t *testing.T) {
 	tests := []struct {
-		name   string
-		bs     BitSet
-		expect []int
+		name         string
+		bs           BitSet
+		m            int
+		nextN, prevN int
 	}{
-		{"empty", New(), []int{}},
-		{"single", New(0), []int{0}},
-		{"several", New(1, 2, 3, 62, 63, 64), []int{1, 2, 3, 62, 63, 64}},
-		{"large", New(1, 22, 333, 4444), []int{1, 22, 333, 4444}},
+		{"one word neg", BitSet{0b1000, 0}, -1, 3, -1},
+		{"one word below", BitSet{0b1000, 0}, 2, 3, -1},
+		{"one word on max", BitSet{0b1000, 0}, 3, -1, -1},
+		{"one word above", BitSet{0b1000, 0}, 4, -1, 3},
+		{"one word in zero word", BitSet{0b1000, 0}, 100, -1, 3},
+		{"one word past the end", BitSet{0b1000, 0}, 1000, -1, 3},
+		{"zeros around neg", BitSet{0, 0b1, 0, 0}, -1, 64, -1},
+		{"zeros around below", BitSet{0, 0b1, 0, 0}, 63, 64, -1},
+		{"zeros around on max", BitSet{0, 0b1, 0, 0}, 64, -1, -1},
+		{"zeros around above", BitSet{0, 0b1, 0, 0}, 65, -1, 64},
+		{"zeros around in zero word", BitSet{0, 0b1, 0, 0}, 200, -1, 64},
+		{"zeros around past the end", BitSet{0, 0b1, 0, 0}, 1000, -1, 64},
+		{"all zero", BitSet{0, 0}, 100, -1, -1},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			visited := make([]int, 0)
-			tt.bs.Visit(func(n int) bool {
-				visited = append(visited, n)
-				return false
-			})
-			require.Equal(t, tt.expect, visited)
+			trimmed := tt.bs[:tt.bs.trimmedLen()]
+			require.Equal(t, tt.nextN, tt.bs.Next(tt.m))
+			require.Equal(t, tt.prevN, tt.bs.Prev(tt.m))
+			require.Equal(t, trimmed.Next(tt.m), tt.bs.Next(tt.m))
+			require.Equal(t, trimmed.Prev(tt.m), tt.bs.Prev(tt.m))
 		})
 	}
-
-	t.Run("abort early", func(t *testing.T) {
-		bs := New(1, 2)
-		count := 0
-		aborted := bs.Visit(func(n int) bool {
-			count++
-			return n == 1
-		})
-		require.True(t, aborted)
-		require.Equal(t, 1, count)
-	})
 }
 
-func TestBitSet_VisitAll(t *testing.T) {
-	bs := New(0, 2, 63, 64, 100, 300)
-	visited := make([]int, 0)
-	bs.VisitAll(func(n int) {
-		visited = append(visited, n)
+func TestBitSet_NextMany(t *testing.T) {
+	ranges := New(0, 2, 63, 64, 100, 300)
+	ranges.AddRange(120, 140)
+	ranges.AddRange(500, 700)
+	tests := []struct {
+		name string
+		bs   BitSet
+	}{
+		{"empty", New()},
+		{"single", New(5)},
+		{"next prev fixture", New(0, 2, 63, 64, 100, 300)},
+		{"ranges", ranges},
+		{"full words", FromRange(0, 256)},
+		{"untrimmed", BitSet{0b101, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int{}
+			buf := make([]int, 0, 3)
+			for m := -1; ; {
+				page := tt.bs.NextMany(m, buf[:0])
+				if len(page) == 0 {
+					break
+				}
+				require.LessOrEqual(t, len(page), 3)
+				got = append(got, page...)
+				m = page[len(page)-1]
+			}
+			require.Equal(t, tt.bs.ToSlice(), got)
+		})
+	}
+
+	t.Run("append", func(t *testing.T) {
+		bs := New(1, 2, 3, 70)
+		buf := make([]int, 1, 3)
+		buf[0] = -5
+		require.Equal(t, []int{-5, 2, 3}, bs.NextMany(1, buf))
+		require.Equal(t, []int{-5}, bs.NextMany(-1, buf[:1:1]), "full buffer")
+		require.Empty(t, bs.NextMany(70, buf[:0]))
+		require.Empty(t, bs.NextMany(math.MaxInt, buf[:0]))
+		require.Equal(t, []int{70}, bs.NextMany(63, buf[:0]))
+	})
+}
+
+func TestBitSet_NextPrevClear(t *testing.T) {
+	bs := New(0, 1, 2, 62, 63, 64, 100, 300)
+	full := New()
+	full.AddRange(0, 576)
+	tests := []struct {
+		name  string
+		bs    BitSet
+		m     int
+		nextN int
+		prevN int
+	}{
+		{"empty", New(), 1, 2, 0},
+		{"empty zero", New(), 0, 1, -1},
+		{"empty neg", New(), -1, 0, -1},
+
+		{"set neg", bs, -1, 3, -1},
+		{"set on 0", bs, 0, 3, -1},
+		{"set on 3", bs, 3, 4, -1},
+		{"set on 4", bs, 4, 5, 3},
+		{"set between 2 and 62", bs, 50, 51, 49},
+		{"set on 61", bs, 61, 65, 60},
+		{"set on 62", bs, 62, 65, 61},
+		{"set on 65", bs, 65, 66, 61},
+		{"set on 100", bs, 100, 101, 99},
+		{"set on 300", bs, 300, 301, 299},
+		{"set after max", bs, 1000, 1001, 999},
+
+		{"full neg", full, -1, 576, -1},
+		{"full on 0", full, 0, 576, -1},
+		{"full inside", full, 300, 576, -1},
+		{"full on 575", full, 575, 576, -1},
+		{"full on 576", full, 576, 577, -1},
+		{"full on 577", full, 577, 578, 576},
+
+		{"empty on MaxInt", New(), math.MaxInt, -1, math.MaxInt - 1},
+		{"set on MaxInt", New(0, 1, 2), math.MaxInt, -1, math.MaxInt - 1},
+		{"full on MaxInt", full, math.MaxInt, -1, math.MaxInt - 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.nextN, tt.bs.NextClear(tt.m))
+			require.Equal(t, tt.prevN, tt.bs.PrevClear(tt.m))
+		})
+	}
+}
+
+func TestBitSet_Visit(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect []int
+	}{
+		{"empty", New(), []int{}},
+		{"single", New(0), []int{0}},
+		{"several", New(1, 2, 3, 62, 63, 64), []int{1, 2, 3, 62, 63, 64}},
+		{"large", New(1, 22, 333, 4444), []int{1, 22, 333, 4444}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visited := make([]int, 0)
+			tt.bs.Visit(func(n int) bool {
+				visited = append(visited, n)
+				return false
+			})
+			require.Equal(t, tt.expect, visited)
+		})
+	}
+
+	t.Run("abort early", func(t *testing.T) {
+		bs := New(1, 2)
+		count := 0
+		aborted := bs.Visit(func(n int) bool {
+			count++
+			return n == 1
+		})
+		require.True(t, aborted)
+		require.Equal(t, 1, count)
+	})
+}
+
+func TestBitSet_EveryAnyNone(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	tests := []struct {
+		name             string
+		bs               BitSet
+		every, any, none bool
+	}{
+		{"empty", New(), true, false, true},
+		{"all even", New(0, 2, 64, 100), true, true, false},
+		{"none even", New(1, 63, 65), false, false, true},
+		{"mixed", New(1, 2, 3), false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.every, tt.bs.Every(even))
+			require.Equal(t, tt.any, tt.bs.Any(even))
+			require.Equal(t, tt.none, tt.bs.None(even))
+		})
+	}
+
+	t.Run("abort early", func(t *testing.T) {
+		bs := New(1, 2, 3, 64, 200)
+		count := 0
+		counting := func(pred func(int) bool) func(int) bool {
+			count = 0
+			return func(n int) bool {
+				count++
+				return pred(n)
+			}
+		}
+		require.False(t, bs.Every(counting(func(n int) bool { return n < 3 })))
+		require.Equal(t, 3, count)
+		require.True(t, bs.Any(counting(func(n int) bool { return n == 64 })))
+		require.Equal(t, 4, count)
+		require.False(t, bs.None(counting(even)))
+		require.Equal(t, 2, count)
+		require.True(t, bs.Every(counting(func(n int) bool { return n > 0 })))
+		require.Equal(t, 5, count)
+	})
+}
+
+func TestBitSet_VisitGrowing(t *testing.T) {
+	t.Run("worklist", func(t *testing.T) {
+		bs := New(1, 2)
+		visits := map[int]int{}
+		bs.VisitGrowing(func(n int) bool {
+			visits[n]++
+			if n+100 < 1000 {
+				bs.Add(n + 100)
+			}
+			bs.Add(n) // re-adding a visited element has no effect
+			return false
+		})
+
+		expect := New()
+		for n := 1; n < 1000; n += 100 {
+			expect.Add(n, n+1)
+		}
+		require.True(t, bs.Equal(expect))
+		require.Len(t, visits, expect.Size())
+		for n, count := range visits {
+			require.Equal(t, 1, count, n)
+		}
+	})
+
+	t.Run("delete ahead", func(t *testing.T) {
+		bs := New(1, 5, 70, 200)
+		var visited []int
+		bs.VisitGrowing(func(n int) bool {
+			visited = append(visited, n)
+			bs.Delete(70)
+			return false
+		})
+		require.Equal(t, []int{1, 5, 200}, visited)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		bs := New(1, 5)
+		var visited []int
+		aborted := bs.VisitGrowing(func(n int) bool {
+			visited = append(visited, n)
+			bs.Add(n + 1)
+			return n == 3
+		})
+		require.True(t, aborted)
+		require.Equal(t, []int{1, 2, 3}, visited)
+	})
+}
+
+func TestBitSet_VisitRange(t *testing.T) {
+	bs := New(0, 2, 5, 63, 64, 65, 100, 300)
+	tests := []struct {
+		name   string
+		bs     BitSet
+		m, n   int
+		expect []int
+	}{
+		{"empty set", New(), 0, 100, []int{}},
+		{"empty range", bs, 5, 5, []int{}},
+		{"reversed range", bs, 10, 5, []int{}},
+		{"neg range", bs, -10, -1, []int{}},
+		{"clamp neg", bs, -10, 3, []int{0, 2}},
+		{"middle of same word", bs, 1, 6, []int{2, 5}},
+		{"inclusive start exclusive end", bs, 2, 5, []int{2}},
+		{"word boundary", bs, 63, 65, []int{63, 64}},
+		{"span words", bs, 3, 101, []int{5, 63, 64, 65, 100}},
+		{"past last word", bs, 100, 100000, []int{100, 300}},
+		{"start past last word", bs, 1000, 2000, []int{}},
+		{"whole set", bs, 0, 301, []int{0, 2, 5, 63, 64, 65, 100, 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visited := make([]int, 0)
+			aborted := tt.bs.VisitRange(tt.m, tt.n, func(n int) bool {
+				visited = append(visited, n)
+				return false
+			})
+			require.False(t, aborted)
+			require.Equal(t, tt.expect, visited)
+		})
+	}
+
+	t.Run("abort early", func(t *testing.T) {
+		count := 0
+		aborted := bs.VisitRange(3, 200, func(n int) bool {
+			count++
+			return n == 63
+		})
+		require.True(t, aborted)
+		require.Equal(t, 2, count)
+	})
+}
+
+func TestBitSet_VisitDescending(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect []int
+	}{
+		{"empty", New(), []int{}},
+		{"single", New(0), []int{0}},
+		{"next prev fixture", New(0, 2, 63, 64, 100, 300), []int{300, 100, 64, 63, 2, 0}},
+		{"large", New(1, 22, 333, 4444), []int{4444, 333, 22, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visited := make([]int, 0)
+			aborted := tt.bs.VisitDescending(func(n int) bool {
+				visited = append(visited, n)
+				return false
+			})
+			require.False(t, aborted)
+			require.Equal(t, tt.expect, visited)
+		})
+	}
+
+	t.Run("abort early", func(t *testing.T) {
+		bs := New(1, 2, 100)
+		count := 0
+		aborted := bs.VisitDescending(func(n int) bool {
+			count++
+			return n == 100
+		})
+		require.True(t, aborted)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("delete visited", func(t *testing.T) {
+		bs := New(1, 2, 64, 100)
+		var visited []int
+		bs.VisitDescending(func(n int) bool {
+			visited = append(visited, n)
+			bs.Delete(n)
+			return false
+		})
+		require.Equal(t, []int{100, 64, 2, 1}, visited)
+		require.True(t, bs.Empty())
+	})
+}
+
+func TestBitSet_VisitAll(t *testing.T) {
+	bs := New(0, 2, 63, 64, 100, 300)
+	visited := make([]int, 0)
+	bs.VisitAll(func(n int) {
+		visited = append(visited, n)
+	})
+	require.Equal(t, []int{0, 2, 63, 64, 100, 300}, visited)
+}
+
+func TestBitSet_All(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect []int
+	}{
+		{"empty", New(), nil},
+		{"single", New(1), []int{1}},
+		{"boundary", New(0, 63, 64, 65), []int{0, 63, 64, 65}},
+		{"large", New(100, 200, 300), []int{100, 200, 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for n := range tt.bs.All() {
+				got = append(got, n)
+			}
+			require.Equal(t, tt.expect, got)
+
+			got = nil
+			for n := range tt.bs.Backward() {
+				got = append(got, n)
+			}
+			slices.Reverse(got)
+			require.Equal(t, tt.expect, got)
+		})
+	}
+
+	t.Run("break early", func(t *testing.T) {
+		bs := New(1, 2, 3, 100, 200)
+		var got []int
+		for n := range bs.All() {
+			if n > 2 {
+				break
+			}
+			got = append(got, n)
+		}
+		require.Equal(t, []int{1, 2}, got)
+
+		got = nil
+		for n := range bs.Backward() {
+			if n < 100 {
+				break
+			}
+			got = append(got, n)
+		}
+		require.Equal(t, []int{200, 100}, got)
+	})
+}
+
+func TestBitSet_Ranges(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect [][2]int
+	}{
+		{"empty", New(), nil},
+		{"scattered", New(1, 3, 5), [][2]int{{1, 1}, {3, 3}, {5, 5}}},
+		{"range 0 to 1000", func() BitSet {
+			b := New()
+			b.AddRange(0, 1000)
+			return b
+		}(), [][2]int{{0, 999}}},
+		{"cross word boundary", New(62, 63, 64, 65, 200), [][2]int{{62, 65}, {200, 200}}},
+		{"ends on word boundary", func() BitSet {
+			b := New(130)
+			b.AddRange(64, 128)
+			return b
+		}(), [][2]int{{64, 127}, {130, 130}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]int
+			for start, end := range tt.bs.Ranges() {
+				got = append(got, [2]int{start, end})
+			}
+			require.Equal(t, tt.expect, got)
+		})
+	}
+
+	t.Run("break early", func(t *testing.T) {
+		count := 0
+		for range New(1, 3, 5).Ranges() {
+			count++
+			break
+		}
+		require.Equal(t, 1, count)
+	})
+}
+
+func TestBitSet_Gaps(t *testing.T) {
+	holes := func(m, n int, holes ...int) BitSet {
+		b := FromRange(m, n)
+		b.Delete(holes...)
+		return b
+	}
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect [][2]int
+	}{
+		{"empty", New(), nil},
+		{"single", New(5), nil},
+		{"contiguous", FromRange(10, 300), nil},
+		{"example", New(0, 5, 6, 100), [][2]int{{1, 4}, {7, 99}}},
+		{"hole at 63", holes(0, 200, 63), [][2]int{{63, 63}}},
+		{"hole at 64", holes(0, 200, 64), [][2]int{{64, 64}}},
+		{"holes at 63 and 64", holes(0, 200, 63, 64), [][2]int{{63, 64}}},
+		{"holes at word boundaries", holes(1, 300, 64, 127, 128, 192),
+			[][2]int{{64, 64}, {127, 128}, {192, 192}}},
+		{"whole words", New(0, 300), [][2]int{{1, 299}}},
+		{"untrimmed", BitSet{0b1001, 0}, [][2]int{{1, 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]int
+			for start, end := range tt.bs.Gaps() {
+				got = append(got, [2]int{start, end})
+			}
+			require.Equal(t, tt.expect, got)
+
+			// The gaps are the runs of the complement within the hull.
+			var want [][2]int
+			if !tt.bs.Empty() {
+				c := tt.bs.Complement(tt.bs.Max())
+				c.DeleteRange(0, tt.bs.Min())
+				for start, end := range c.Ranges() {
+					want = append(want, [2]int{start, end})
+				}
+			}
+			require.Equal(t, want, got)
+		})
+	}
+
+	t.Run("break early", func(t *testing.T) {
+		count := 0
+		for range New(1, 3, 5).Gaps() {
+			count++
+			break
+		}
+		require.Equal(t, 1, count)
+	})
+}
+
+func TestBitSet_LongestRunGap(t *testing.T) {
+	tests := []struct {
+		name                string
+		bs                  BitSet
+		runStart, runLength int
+		gapStart, gapLength int
+	}{
+		{"empty", New(), -1, 0, -1, 0},
+		{"untrimmed empty", BitSet{0}, -1, 0, -1, 0},
+		{"single", New(5), 5, 1, -1, 0},
+		{"range", FromRange(10, 1000), 10, 990, -1, 0},
+		{"word-wide gap", func() BitSet {
+			b := FromRange(0, 256)
+			b.DeleteRange(128, 192)
+			return b
+		}(), 0, 128, 128, 64},
+		{"ties take the first", New(1, 2, 5, 6, 9), 1, 2, 3, 2},
+		{"runs across words", New(0, 62, 63, 64, 65, 66, 200, 1000), 62, 5, 201, 799},
+		{"untrimmed", BitSet{0b1001, 0}, 0, 1, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, length := tt.bs.LongestRun()
+			require.Equal(t, [2]int{tt.runStart, tt.runLength}, [2]int{start, length})
+			start, length = tt.bs.LongestGap()
+			require.Equal(t, [2]int{tt.gapStart, tt.gapLength}, [2]int{start, length})
+		})
+	}
+}
+
+func TestBitSet_NumRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		bs         BitSet
+		expect     int
+		contiguous bool
+	}{
+		{"empty", New(), 0, true},
+		{"single", New(5), 1, true},
+		{"scattered", New(1, 3, 5), 3, false},
+		{"range 0 to 576", func() BitSet {
+			b := New()
+			b.AddRange(0, 576)
+			return b
+		}(), 1, true},
+		{"cross word boundary", New(62, 63, 64, 65), 1, true},
+		{"cross word boundary and more", New(62, 63, 64, 65, 200), 2, false},
+		{"full word then gap", func() BitSet {
+			b := New(129)
+			b.AddRange(0, 128)
+			return b
+		}(), 2, false},
+		{"full words", func() BitSet {
+			b := New()
+			b.AddRange(64, 256)
+			return b
+		}(), 1, true},
+		{"bits 63 and 0 of next word apart", New(63, 128), 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs.NumRanges())
+			require.Equal(t, tt.contiguous, tt.bs.IsContiguous())
+
+			runs := 0
+			for range tt.bs.Ranges() {
+				runs++
+			}
+			require.Equal(t, runs, tt.bs.NumRanges())
+		})
+	}
+}
+
+func TestBitSet_ToSlice(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect []int
+	}{
+		{"empty", New(), []int{}},
+		{"single", New(1), []int{1}},
+		{"boundary", New(63, 64, 65), []int{63, 64, 65}},
+		{"large", New(0, 100, 200, 300), []int{0, 100, 200, 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.bs.ToSlice()
+			require.NotNil(t, got)
+			require.Equal(t, tt.expect, got)
+			require.Equal(t, tt.bs.Size(), cap(got))
+			require.True(t, New(got...).Equal(tt.bs))
+
+			appended := tt.bs.AppendTo(nil)
+			require.NotNil(t, appended)
+			require.Equal(t, tt.expect, appended)
+
+			buf := make([]int, 1, 16)
+			buf[0] = -1
+			require.Equal(t, append([]int{-1}, tt.expect...), tt.bs.AppendTo(buf))
+		})
+	}
+}
+
+func TestBitSet_MaxNMinN(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	sets := []BitSet{New(), New(0), New(63, 64, 65), BitSet{0b101, 0}}
+	for range 10 {
+		bs := New()
+		for range r.IntN(300) {
+			bs.Add(r.IntN(3000))
+		}
+		sets = append(sets, bs)
+	}
+
+	for _, bs := range sets {
+		asc := bs.ToSlice()
+		desc := slices.Clone(asc)
+		slices.Reverse(desc)
+		for _, k := range []int{-1, 0, 1, 2, 63, 64, 65, len(asc), len(asc) + 1} {
+			wantMax, wantMin := desc[:max(0, min(k, len(desc)))], asc[:max(0, min(k, len(asc)))]
+			gotMax, gotMin := bs.MaxN(k, nil), bs.MinN(k, nil)
+			require.NotNil(t, gotMax)
+			require.NotNil(t, gotMin)
+			require.Equal(t, wantMax, gotMax, "MaxN(%d) of %v", k, bs)
+			require.Equal(t, wantMin, gotMin, "MinN(%d) of %v", k, bs)
+
+			buf := make([]int, 1, len(asc)+1)
+			buf[0] = -1
+			require.Equal(t, append([]int{-1}, wantMax...), bs.MaxN(k, buf))
+			require.Equal(t, append([]int{-1}, wantMin...), bs.MinN(k, buf))
+			require.Zero(t, testing.AllocsPerRun(10, func() {
+				bs.MaxN(k, buf[:0])
+				bs.MinN(k, buf[:0])
+			}))
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []uint64
+		set   string
+	}{
+		{"nil", nil, "{}"},
+		{"all zero", []uint64{0, 0, 0}, "{}"},
+		{"single", []uint64{0b101}, "{0 2}"},
+		{"trailing zeros", []uint64{1, 1, 0, 0}, "{0 64}"},
+		{"leading zeros", []uint64{0, 0, 1 << 63}, "{191}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := slices.Clone(tt.words)
+			bs := FromWords(words)
+			require.Equal(t, tt.set, bs.String())
+			require.NoError(t, bs.Validate())
+			require.Equal(t, tt.set == "{}", bs.Empty())
+			require.True(t, FromWords(bs.Words()).Equal(bs))
+
+			if len(words) > 0 {
+				words[0] = 1 << 10
+				require.False(t, bs.Contains(10), "FromWords must copy")
+			}
+			w := bs.Words()
+			if len(w) > 0 {
+				w[0] = 1 << 11
+				require.False(t, bs.Contains(11), "Words must copy")
+			}
+		})
+	}
+}
+
+func TestBools(t *testing.T) {
+	t.Run("fixtures", func(t *testing.T) {
+		require.True(t, FromBools(nil).Empty())
+		require.True(t, FromBools(make([]bool, 100)).Empty())
+		require.Equal(t, "{0 2}", FromBools([]bool{true, false, true, false}).String())
+		require.Equal(t, []bool{false, true, false}, New(1, 5).ToBools(3))
+		require.Equal(t, []bool{false, true, false, false, false, true}, New(1, 5).ToBools(-1))
+		require.Equal(t, []bool{false, true, false, false, false, true, false}, New(1, 5).ToBools(7))
+		require.Empty(t, New().ToBools(-1))
+		require.Empty(t, New(3).ToBools(0))
+	})
+
+	r := rand.New(rand.NewPCG(11, 12))
+	for _, last := range []int{0, 1, 63, 64, 65, 127, 128, 1000} {
+		t.Run(fmt.Sprintf("last %d", last), func(t *testing.T) {
+			for range 20 {
+				mask := make([]bool, last+1+r.IntN(3))
+				for i := range last {
+					mask[i] = r.IntN(2) == 0
+				}
+				mask[last] = true
+
+				bs := FromBools(mask)
+				require.NoError(t, bs.Validate())
+				require.Equal(t, last, bs.Max())
+				for i, v := range mask {
+					require.Equal(t, v, bs.Contains(i), i)
+				}
+				require.Equal(t, mask, bs.ToBools(len(mask)))
+				require.Equal(t, mask[:last+1], bs.ToBools(-1))
+			}
+		})
+	}
+}
+
+func TestBitSet_Word(t *testing.T) {
+	bs := New(1, 64, 130)
+	require.Equal(t, uint64(0b10), bs.Word(0))
+	require.Equal(t, uint64(1), bs.Word(1))
+	require.Equal(t, uint64(0b100), bs.Word(2))
+	require.Zero(t, bs.Word(3))
+	require.Zero(t, bs.Word(-1))
+	require.Zero(t, BitSet(nil).Word(0))
+}
+
+func TestBitSet_Add(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  BitSet
+		add    []int
+		expect string
+	}{
+		{"add neg", New(), []int{-1}, "{}"},
+		{"add single", New(), []int{1}, "{1}"},
+		{"add duplicate", New(1), []int{1}, "{1}"},
+		{"add new", New(1), []int{2}, "{1 2}"},
+		{"add 64", New(), []int{64}, "{64}"},
+		{"add large", New(), []int{100, 200, 300}, "{100 200 300}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variadic := tt.start.Copy()
+			for _, v := range tt.add {
+				tt.start.Add(v)
+			}
+			require.Equal(t, tt.expect, tt.start.String())
+
+			variadic.Add(tt.add...)
+			require.Equal(t, tt.expect, variadic.String())
+		})
+	}
+
+	t.Run("mixed sign", func(t *testing.T) {
+		bs := New()
+		bs.Add(-5, 3, -1, 70)
+		require.Equal(t, "{3 70}", bs.String())
+	})
+
+	t.Run("single allocation", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(100, func() {
+			bs := New()
+			bs.Add(1, 500, 1000)
+		})
+		require.Equal(t, 1.0, allocs)
+	})
+}
+
+func TestBitSet_AddDeleteSlice(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	for _, size := range []int{0, 1, 10, 1000} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			ns := make([]int, size)
+			for i := range ns {
+				ns[i] = r.IntN(2000) - 100
+			}
+
+			var bs, want BitSet
+			bs.AddSlice(ns)
+			for _, e := range ns {
+				want.Add(e)
+			}
+			require.True(t, want.Equal(bs))
+			require.NoError(t, bs.Validate())
+
+			del := ns[:len(ns)/2]
+			bs.DeleteSlice(del)
+			for _, e := range del {
+				want.Delete(e)
+			}
+			require.True(t, want.Equal(bs))
+			require.NoError(t, bs.Validate())
+		})
+	}
+
+	t.Run("allocs", func(t *testing.T) {
+		ns := make([]int, 10000)
+		for i := range ns {
+			ns[i] = r.IntN(100000)
+		}
+		require.Equal(t, 1.0, testing.AllocsPerRun(10, func() {
+			var bs BitSet
+			bs.AddSlice(ns)
+		}))
+		bs := FromRange(0, 100000)
+		require.Zero(t, testing.AllocsPerRun(10, func() {
+			bs.AddSlice(ns)
+			bs.DeleteSlice(ns)
+		}))
+	})
+}
+
+func TestBitSet_Delete(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  BitSet
+		del    []int
+		expect string
+	}{
+		{"del present", New(1), []int{1}, "{}"},
+		{"del neg", New(1), []int{-1}, "{1}"},
+		{"del absent", New(1), []int{2}, "{1}"},
+		{"del 64 from 65", New(65), []int{64}, "{65}"},
+		{"del 200", New(100, 200, 300), []int{200}, "{100 300}"},
+		{"del 300", New(100, 200, 300), []int{300}, "{100 200}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variadic := tt.start.Copy()
+			for _, v := range tt.del {
+				tt.start.Delete(v)
+			}
+			require.Equal(t, tt.expect, tt.start.String())
+
+			variadic.Delete(tt.del...)
+			require.Equal(t, tt.expect, variadic.String())
+		})
+	}
+
+	t.Run("several with trim", func(t *testing.T) {
+		bs := New(1, 64, 100, 300)
+		bs.Delete(300, -1, 100, 5000)
+		require.Equal(t, "{1 64}", bs.String())
+		require.Len(t, bs, 2)
+	})
+}
+
+func TestBitSet_DeleteTop(t *testing.T) {
+	bs := New(1, 64, 100, 1000)
+	bs.Delete(100)
+	require.Len(t, bs, 16, "interior delete keeps the length")
+	bs.Delete(1000)
+	require.Equal(t, 64, bs.Max())
+	require.True(t, bs.Equal(New(1, 64)))
+	require.Equal(t, New(1, 64), bs)
+	require.NoError(t, bs.Validate())
+
+	bs.Delete(64, 1)
+	require.Equal(t, -1, bs.Max())
+	require.True(t, bs.Empty())
+	require.True(t, bs.Equal(New()))
+	require.Empty(t, bs)
+
+	bs = New(1, 64, 100, 1000)
+	bs.DeleteRange(900, 2000)
+	require.Equal(t, 100, bs.Max())
+	require.Equal(t, New(1, 64, 100), bs)
+	bs.DeleteRange(64, 65)
+	require.Equal(t, New(1, 100), bs)
+	bs.DeleteRange(0, 101)
+	require.Empty(t, bs)
+	require.NoError(t, bs.Validate())
+}
+
+func TestBitSet_SetBit(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []int
+		n      int
+		v      bool
+		after  string
+	}{
+		{"set neg", []int{1}, -1, true, "{1}"},
+		{"clear neg", []int{1}, -1, false, "{1}"},
+		{"set absent", []int{1}, 64, true, "{1 64}"},
+		{"set present", []int{1}, 1, true, "{1}"},
+		{"clear present", []int{1, 64}, 64, false, "{1}"},
+		{"clear absent", []int{1}, 300, false, "{1}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			bs.SetBit(tt.n, tt.v)
+			require.Equal(t, tt.after, bs.String())
+		})
+	}
+}
+
+func TestBitSet_TestAndSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []int
+		n      int
+		was    bool
+		after  string
+	}{
+		{"neg", []int{1}, -1, false, "{1}"},
+		{"absent", []int{1}, 2, false, "{1 2}"},
+		{"present", []int{1, 2}, 2, true, "{1 2}"},
+		{"grow", []int{1}, 64, false, "{1 64}"},
+		{"empty", nil, 300, false, "{300}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			require.Equal(t, tt.was, bs.TestAndSet(tt.n))
+			require.Equal(t, tt.after, bs.String())
+		})
+	}
+
+	t.Run("claim free id", func(t *testing.T) {
+		bs := New()
+		require.False(t, bs.TestAndSet(7))
+		require.True(t, bs.TestAndSet(7))
+	})
+}
+
+func TestBitSet_TestAndClear(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []int
+		n      int
+		was    bool
+		after  string
+	}{
+		{"neg", []int{1}, -1, false, "{1}"},
+		{"absent", []int{1}, 2, false, "{1}"},
+		{"absent beyond", []int{1}, 300, false, "{1}"},
+		{"present", []int{1, 2}, 2, true, "{1}"},
+		{"present top", []int{1, 300}, 300, true, "{1}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			require.Equal(t, tt.was, bs.TestAndClear(tt.n))
+			require.Equal(t, tt.after, bs.String())
+			require.True(t, bs.Empty() || bs[len(bs)-1] != 0)
+		})
+	}
+}
+
+func TestBitSet_PopMinMax(t *testing.T) {
+	t.Run("pop min", func(t *testing.T) {
+		bs := New(3, 64, 65, 700)
+		var popped []int
+		for range 6 {
+			popped = append(popped, bs.PopMin())
+		}
+		require.Equal(t, []int{3, 64, 65, 700, -1, -1}, popped)
+		require.True(t, bs.Empty())
+		require.Len(t, bs, 0)
+	})
+
+	t.Run("pop max", func(t *testing.T) {
+		bs := New(3, 64, 65, 700)
+		var popped []int
+		for range 6 {
+			popped = append(popped, bs.PopMax())
+			require.True(t, bs.Empty() || bs[len(bs)-1] != 0)
+		}
+		require.Equal(t, []int{700, 65, 64, 3, -1, -1}, popped)
+		require.True(t, bs.Empty())
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		bs := New(1, 2, 130)
+		require.Equal(t, 130, bs.PopMax())
+		require.Len(t, bs, 1)
+		require.Equal(t, 1, bs.PopMin())
+		require.Equal(t, "{2}", bs.String())
 	})
-	require.Equal(t, []int{0, 2, 63, 64, 100, 300}, visited)
 }
 
-func TestBitSet_Add(t *testing.T) {
+func TestBitSet_Partition(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 8))
+	sets := []BitSet{New(), New(0), BitSet{0b11, 0}}
+	for range 10 {
+		bs := New()
+		for range r.IntN(300) {
+			bs.Add(r.IntN(2000))
+		}
+		sets = append(sets, bs)
+	}
+	preds := map[string]func(int) bool{
+		"all true":  func(int) bool { return true },
+		"all false": func(int) bool { return false },
+		"even":      func(n int) bool { return n%2 == 0 },
+		"below 700": func(n int) bool { return n < 700 },
+		"random":    func(int) bool { return r.IntN(2) == 0 },
+	}
+
+	for _, bs := range sets {
+		for name, pred := range preds {
+			prev := -1
+			yes, no := bs.Partition(func(n int) bool {
+				require.Greater(t, n, prev, "numerical order")
+				prev = n
+				return pred(n)
+			})
+			require.NoError(t, yes.Validate())
+			require.NoError(t, no.Validate())
+			require.True(t, Or(yes, no).Equal(bs), "%s of %v", name, bs)
+			require.True(t, yes.Disjoint(no), "%s of %v", name, bs)
+			switch name {
+			case "all true":
+				require.True(t, yes.Equal(bs))
+				require.True(t, no.Empty())
+			case "all false":
+				require.True(t, yes.Empty())
+				require.True(t, no.Equal(bs))
+			case "even":
+				yes.VisitAll(func(n int) { require.Zero(t, n%2) })
+				no.VisitAll(func(n int) { require.NotZero(t, n%2) })
+			}
+		}
+	}
+}
+
+func TestBitSet_Map(t *testing.T) {
+	bs := New(0, 1, 63, 64, 100, 300)
+	for _, k := range []int{0, 1, 63, 64, 65, 1000} {
+		want := bs.Copy()
+		want.ShiftLeft(k)
+		var calls []int
+		got := bs.Map(func(n int) int {
+			calls = append(calls, n)
+			return n + k
+		})
+		require.Equal(t, want, got, "+%d", k)
+		require.Equal(t, bs.ToSlice(), calls, "f is called once per element")
+	}
+
+	require.Equal(t, BitSet{}, New().Map(func(n int) int { return n }))
+	require.Equal(t, "{}", bs.Map(func(int) int { return -1 }).String())
+	require.Equal(t, "{30 31 49 149}", bs.Map(func(n int) int { return n/2 - 1 }).String())
+	require.Equal(t, "{0 200 236 237 299 300}", bs.Map(func(n int) int { return 300 - n }).String())
+}
+
+func TestBitSet_Compact(t *testing.T) {
+	index := New(3, 10, 63, 64, 65, 200, 1000)
+	rank := map[int]int{3: 0, 10: 1, 63: 2, 64: 3, 65: 4, 200: 5, 1000: 6}
 	tests := []struct {
-		name   string
-		start  BitSet
-		add    []int
-		expect string
+		name string
+		bs   BitSet
 	}{
-		{"add neg", New(), []int{-1}, "{}"},
-		{"add single", New(), []int{1}, "{1}"},
-		{"add duplicate", New(1), []int{1}, "{1}"},
-		{"add new", New(1), []int{2}, "{1 2}"},
-		{"add 64", New(), []int{64}, "{64}"},
-		{"add large", New(), []int{100, 200, 300}, "{100 200 300}"},
+		{"empty", New()},
+		{"disjoint", New(0, 4, 66, 5000)},
+		{"same", index.Copy()},
+		{"some", New(10, 64, 1000)},
+		{"with absent", New(0, 3, 11, 65, 999, 1000, 5000)},
+		{"untrimmed", BitSet{1 << 10, 0, 0, 0, 0, 0}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for _, v := range tt.add {
-				tt.start.Add(v)
-			}
-			require.Equal(t, tt.expect, tt.start.String())
+			want := New()
+			tt.bs.VisitAll(func(n int) {
+				if r, ok := rank[n]; ok {
+					want.Add(r)
+				}
+			})
+			got := tt.bs.Compact(index)
+			require.Equal(t, want.String(), got.String())
+			require.NoError(t, got.Validate())
 		})
 	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	large, bs := New(), New()
+	for range 2000 {
+		large.Add(r.IntN(100_000))
+		bs.Add(r.IntN(100_000))
+	}
+	want := New()
+	bs.VisitAll(func(n int) {
+		if large.Contains(n) {
+			want.Add(large.Rank(n))
+		}
+	})
+	require.Equal(t, want, bs.Compact(large))
 }
 
-func TestBitSet_Delete(t *testing.T) {
+func TestBitSet_RemoveIf(t *testing.T) {
+	odd := func(n int) bool { return n%2 == 1 }
 	tests := []struct {
 		name   string
-		start  BitSet
-		del    []int
-		expect string
+		bs     func() BitSet
+		pred   func(n int) bool
+		remove string
+		retain string
 	}{
-		{"del present", New(1), []int{1}, "{}"},
-		{"del neg", New(1), []int{-1}, "{1}"},
-		{"del absent", New(1), []int{2}, "{1}"},
-		{"del 64 from 65", New(65), []int{64}, "{65}"},
-		{"del 200", New(100, 200, 300), []int{200}, "{100 300}"},
-		{"del 300", New(100, 200, 300), []int{300}, "{100 200}"},
+		{"empty", func() BitSet { return New() }, odd, "{}", "{}"},
+		{"odd", func() BitSet {
+			b := New()
+			b.AddRange(0, 10)
+			return b
+		}, odd, "{0 2 4 6 8}", "{1 3 5 7 9}"},
+		{"all", func() BitSet { return New(1, 64, 300) }, func(int) bool { return true }, "{}", "{1 64 300}"},
+		{"none", func() BitSet { return New(1, 64, 300) }, func(int) bool { return false }, "{1 64 300}", "{}"},
+		{"top word", func() BitSet { return New(1, 64, 300) }, func(n int) bool { return n > 100 }, "{1 64}", "{300}"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for _, v := range tt.del {
-				tt.start.Delete(v)
-			}
-			require.Equal(t, tt.expect, tt.start.String())
+			bs := tt.bs()
+			bs.RemoveIf(tt.pred)
+			require.Equal(t, tt.remove, bs.String())
+			require.True(t, bs.Empty() || bs[len(bs)-1] != 0)
+
+			bs = tt.bs()
+			bs.RetainIf(tt.pred)
+			require.Equal(t, tt.retain, bs.String())
+			require.True(t, bs.Empty() || bs[len(bs)-1] != 0)
 		})
 	}
+
+	t.Run("size", func(t *testing.T) {
+		bs := New()
+		bs.AddRange(0, 1000)
+		visited := 0
+		bs.RemoveIf(func(n int) bool {
+			visited++
+			return odd(n)
+		})
+		require.Equal(t, 1000, visited)
+		require.Equal(t, 500, bs.Size())
+	})
 }
 
 func TestBitSet_Reset(t *testing.T) {
@@ -389,6 +2053,26 @@ func TestBitSet_Reset(t *testing.T) {
 	}
 }
 
+func TestBitSet_ResetFree(t *testing.T) {
+	bs := FromRange(0, 100_000)
+	alias := bs
+	bs.ResetFree()
+	require.Zero(t, cap(bs))
+	require.True(t, bs.Empty())
+	require.Equal(t, "{}", bs.String())
+	require.Equal(t, 100_000, alias.Size(), "the old storage is not touched")
+
+	bs.Add(5, 300)
+	require.Equal(t, "{5 300}", bs.String())
+	require.NoError(t, bs.Validate())
+
+	var zero BitSet
+	zero.ResetFree()
+	require.Zero(t, cap(zero))
+	zero.AddRange(10, 20)
+	require.Equal(t, "{10..19}", zero.String())
+}
+
 func TestBitSet_AddRange(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -416,6 +2100,99 @@ func TestBitSet_AddRange(t *testing.T) {
 	}
 }
 
+func TestMaxElement(t *testing.T) {
+	limitErr := func(n int) string {
+		return fmt.Sprintf("bitset: element %d exceeds the maximum of %d", n, MaxElement)
+	}
+
+	t.Run("max int", func(t *testing.T) {
+		if MaxElement == math.MaxInt {
+			t.Skip("every int is a valid element on this platform")
+		}
+		above := MaxElement
+		for _, n := range []int{math.MaxInt, math.MaxInt - 63, above + 2} {
+			bs := New(1)
+			require.PanicsWithError(t, limitErr(n), func() { bs.Add(n) })
+			require.PanicsWithError(t, limitErr(n-1), func() { bs.AddRange(n-1, n) })
+			require.PanicsWithError(t, limitErr(n-1), func() { bs.AddRange(0, n) })
+			require.PanicsWithError(t, limitErr(n), func() { New(n) })
+			require.PanicsWithError(t, limitErr(n-1), func() { FromRange(0, n) })
+			require.EqualError(t, bs.AddCapped(math.MaxInt, 2, n), limitErr(n))
+			require.EqualError(t, bs.AddRangeCapped(math.MaxInt, 0, n), limitErr(n-1))
+			require.PanicsWithError(t, limitErr(n), func() { bs.TestAndSet(n) })
+			require.PanicsWithError(t, limitErr(n), func() { bs.SetBit(n, true) })
+			require.PanicsWithError(t, limitErr(n), func() { bs.Flip(n) })
+			require.PanicsWithError(t, limitErr(n-1), func() { bs.FlipRange(n-1, n) })
+			require.PanicsWithError(t, limitErr(n-1), func() { bs.ComplementRange(0, n) })
+			require.PanicsWithError(t, limitErr(n-1), func() { bs.Complement(n) })
+			require.PanicsWithError(t, limitErr(n), func() { bs.Grow(n) })
+			shiftErr := fmt.Sprintf("bitset: element 1 shifted by %d exceeds the maximum of %d", n, MaxElement)
+			require.PanicsWithError(t, shiftErr, func() { bs.ShiftLeft(n) })
+			var b Builder
+			require.PanicsWithError(t, limitErr(n), func() { b.Add(n) })
+			require.PanicsWithError(t, limitErr(n-1), func() { b.AddRange(0, n) })
+			require.Equal(t, "{1}", bs.String())
+
+			require.False(t, bs.Contains(n))
+			bs.Delete(n)
+			bs.DeleteRange(0, n)
+			require.True(t, bs.Empty())
+		}
+	})
+
+	t.Run("soft cap", func(t *testing.T) {
+		maxElement = 1000
+		t.Cleanup(func() { maxElement = MaxElement })
+
+		bs := New(1000)
+		bs.Add(999)
+		bs.AddRange(900, 1001)
+		require.Equal(t, "{900..1000}", bs.String())
+		require.NoError(t, bs.AddCapped(math.MaxInt, 0))
+		require.NoError(t, bs.AddRangeCapped(2000, 1, 3))
+		require.NoError(t, bs.AddRangeCapped(5, 10, 10))
+		require.Equal(t, "{0..2 900..1000}", bs.String())
+
+		msg := "bitset: element 1001 exceeds the maximum of 1000"
+		require.PanicsWithError(t, msg, func() { bs.Add(1001) })
+		require.PanicsWithError(t, msg, func() { bs.AddRange(0, 1002) })
+		require.PanicsWithError(t, msg, func() { New(5, 1001) })
+		require.PanicsWithError(t, msg, func() { FromRange(0, 1002) })
+		require.EqualError(t, bs.AddCapped(5000, 1001), msg)
+		require.EqualError(t, bs.AddRangeCapped(5000, 1000, 1002), msg)
+		require.EqualError(t, bs.AddCapped(10, 11), "bitset: element 11 exceeds the maximum of 10")
+		require.EqualError(t, bs.AddRangeCapped(10, 5, 12), "bitset: element 11 exceeds the maximum of 10")
+		_, err := NewCapped(math.MaxInt, 1001)
+		require.EqualError(t, err, msg)
+		require.PanicsWithError(t, msg, func() { bs.TestAndSet(1001) })
+		require.PanicsWithError(t, msg, func() { bs.Flip(1001) })
+		require.PanicsWithError(t, msg, func() { bs.FlipRange(1000, 1002) })
+		require.PanicsWithError(t, msg, func() { bs.Complement(1002) })
+		require.PanicsWithError(t, msg, func() { bs.Grow(1001) })
+		require.PanicsWithError(t, "bitset: element 1000 shifted by 1 exceeds the maximum of 1000",
+			func() { bs.ShiftLeft(1) })
+		var b Builder
+		require.PanicsWithError(t, msg, func() { b.Add(1001) })
+		require.PanicsWithError(t, msg, func() { b.AddRange(1000, 1002) })
+		require.Equal(t, "{0..2 900..1000}", bs.String())
+
+		// The paths stay usable up to the limit.
+		require.False(t, bs.TestAndSet(500))
+		bs.Flip(500)
+		bs.Flip(3)
+		bs.FlipRange(3, 5)
+		require.Equal(t, "{0..2 4 900..1000}", bs.String())
+		require.True(t, bs.Complement(1001).Complement(1001).Equal(bs))
+		bs.Grow(1000)
+		bs.Delete(1000)
+		bs.ShiftLeft(1)
+		require.Equal(t, "{1..3 5 901..1000}", bs.String())
+		b.Add(1000)
+		b.AddRange(999, 1001)
+		require.Equal(t, "{999 1000}", b.Build().String())
+	})
+}
+
 func TestBitSet_DeleteRange(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -423,22 +2200,443 @@ func TestBitSet_DeleteRange(t *testing.T) {
 		before []int
 		after  string
 	}{
-		{"empty range", 0, 0, []int{1, 2, 3}, "{1..3}"},
-		{"empty range neg", 2, 1, []int{1, 2, 3}, "{1..3}"},
-		{"neg range", -2, -1, []int{1, 2, 3}, "{1..3}"},
-		{"part neg", -1, 0, []int{0, 1}, "{0 1}"},
-		{"remove part", 1, 3, []int{0, 1, 2, 3, 4}, "{0 3 4}"},
-		{"remove 64", 64, 65, []int{64, 65}, "{65}"},
-		{"remove big", 50, 300, []int{49, 50, 100, 200, 299, 300, 400}, "{49 300 400}"},
+		{"empty range", 0, 0, []int{1, 2, 3}, "{1..3}"},
+		{"empty range neg", 2, 1, []int{1, 2, 3}, "{1..3}"},
+		{"neg range", -2, -1, []int{1, 2, 3}, "{1..3}"},
+		{"part neg", -1, 0, []int{0, 1}, "{0 1}"},
+		{"remove part", 1, 3, []int{0, 1, 2, 3, 4}, "{0 3 4}"},
+		{"remove 64", 64, 65, []int{64, 65}, "{65}"},
+		{"remove big", 50, 300, []int{49, 50, 100, 200, 299, 300, 400}, "{49 300 400}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			bs.DeleteRange(tt.m, tt.n)
+			require.Equal(t, tt.after, bs.String())
+		})
+	}
+}
+
+func TestBitSet_ExtractKeepRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		m, n   int
+		before []int
+		after  string
+	}{
+		{"empty set", 0, 10, nil, "{}"},
+		{"empty range", 0, 0, []int{1, 2, 3}, "{}"},
+		{"empty range neg", 2, 1, []int{1, 2, 3}, "{}"},
+		{"neg range", -2, -1, []int{1, 2, 3}, "{}"},
+		{"part neg", -1, 2, []int{0, 1, 2}, "{0 1}"},
+		{"keep part", 1, 4, []int{0, 1, 2, 3, 4}, "{1..3}"},
+		{"from 0", 0, 64, []int{0, 63, 64}, "{0 63}"},
+		{"aligned", 64, 128, []int{0, 63, 64, 127, 128}, "{64 127}"},
+		{"misaligned", 50, 300, []int{49, 50, 100, 200, 299, 300, 400}, "{50 100 200 299}"},
+		{"past max", 500, 600, []int{49, 50, 100}, "{}"},
+		{"beyond max", 50, 1000, []int{49, 50, 100}, "{50 100}"},
+		{"nothing inside", 65, 300, []int{1, 64, 300, 400}, "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			e := bs.Extract(tt.m, tt.n)
+			require.Equal(t, tt.after, e.String())
+			require.NoError(t, e.Validate())
+			require.Equal(t, len(e), cap(e))
+			require.Equal(t, New(tt.before...), bs)
+
+			bs.KeepRange(tt.m, tt.n)
+			require.Equal(t, tt.after, bs.String())
+			require.NoError(t, bs.Validate())
+		})
+	}
+}
+
+func TestBitSet_Window(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	sets := []BitSet{
+		New(),
+		New(0, 1, 63, 64, 127, 128, 129, 500),
+		FromRange(60, 200),
+	}
+	for range 5 {
+		var bs BitSet
+		for range 100 {
+			bs.Add(r.IntN(700))
+		}
+		sets = append(sets, bs)
+	}
+	bounds := []int{-1, 0, 1, 62, 63, 64, 65, 127, 128, 129, 192, 500, 501, 700}
+	for range 20 {
+		bounds = append(bounds, r.IntN(800))
+	}
+
+	for _, bs := range sets {
+		for _, m := range bounds {
+			for _, n := range bounds {
+				w := bs.Window(m, n)
+				require.NoError(t, w.Validate())
+
+				want := bs.Extract(m, n)
+				want.ShiftRight(m)
+				require.Equal(t, want, w, "Window(%d, %d) of %v", m, n, bs)
+
+				lo := max(0, m) // negative m is clamped like in the other range methods
+				for x := range n - lo {
+					require.Equal(t, bs.Contains(x+lo), w.Contains(x),
+						"Window(%d, %d).Contains(%d) of %v", m, n, x, bs)
+				}
+				require.Less(t, w.Max(), max(0, n-lo))
+			}
+		}
+	}
+}
+
+func TestBitSet_Split(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	sets := []BitSet{
+		New(),
+		New(0),
+		New(0, 1, 63, 64, 127, 128, 129, 500),
+		FromRange(60, 200),
+	}
+	for range 5 {
+		var bs BitSet
+		for range 100 {
+			bs.Add(r.IntN(700))
+		}
+		sets = append(sets, bs)
+	}
+
+	for _, bs := range sets {
+		pivots := []int{-1, 0, 1, 63, 64, 65, 128, bs.Max(), bs.Max() + 1, 1000}
+		for range 10 {
+			pivots = append(pivots, r.IntN(800))
+		}
+		for _, n := range pivots {
+			orig := bs.Copy()
+			lo, hi := bs.Split(n)
+			require.Equal(t, orig, bs)
+			require.NoError(t, lo.Validate())
+			require.NoError(t, hi.Validate())
+			require.True(t, Or(lo, hi).Equal(bs), "Split(%d) of %v", n, bs)
+			require.True(t, lo.Disjoint(hi), "Split(%d) of %v", n, bs)
+			require.Less(t, lo.Max(), max(0, n))
+			if !hi.Empty() {
+				require.GreaterOrEqual(t, hi.Min(), n)
+			}
+
+			tr := bs.Copy()
+			tr.TruncateAt(n)
+			require.NoError(t, tr.Validate())
+			require.Equal(t, lo, tr)
+		}
+	}
+}
+
+func TestBitSet_Flip(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		before []int
+		after  string
+	}{
+		{"neg", -1, []int{1}, "{1}"},
+		{"add to empty", 1, nil, "{1}"},
+		{"remove", 1, []int{1, 2}, "{2}"},
+		{"add 63", 63, []int{64}, "{63 64}"},
+		{"add 64", 64, []int{63}, "{63 64}"},
+		{"remove 64", 64, []int{63, 64}, "{63}"},
+		{"grow", 300, []int{1}, "{1 300}"},
+		{"remove top", 300, []int{1, 300}, "{1}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			bs.Flip(tt.n)
+			require.Equal(t, tt.after, bs.String())
+			require.True(t, bs.Equal(New(bs.ToSlice()...)))
+		})
+	}
+}
+
+func TestBitSet_FlipRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		m, n   int
+		before []int
+		after  string
+	}{
+		{"empty range", 0, 0, []int{1, 2, 3}, "{1..3}"},
+		{"empty range neg", 2, 1, []int{1, 2, 3}, "{1..3}"},
+		{"neg range", -2, -1, []int{1, 2, 3}, "{1..3}"},
+		{"part neg", -1, 2, []int{1, 2}, "{0 2}"},
+		{"empty set", 1, 10, nil, "{1..9}"},
+		{"same word", 1, 5, []int{0, 2, 4, 6}, "{0 1 3 6}"},
+		{"across 63 64", 62, 66, []int{63, 64}, "{62 65}"},
+		{"across many words", 60, 200, []int{0, 100}, "{0 60..99 101..199}"},
+		{"grow by whole word", 64, 128, []int{1}, "{1 64..127}"},
+		{"grow across boundary", 60, 128, []int{1, 60}, "{1 61..127}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			bs.FlipRange(tt.m, tt.n)
+			require.Equal(t, tt.after, bs.String())
+		})
+	}
+
+	t.Run("trim top word", func(t *testing.T) {
+		bs := New(1)
+		bs.AddRange(64, 200)
+		bs.FlipRange(64, 200)
+		require.Equal(t, "{1}", bs.String())
+		require.Len(t, bs, 1)
+	})
+}
+
+func TestBitSet_Complement(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		before []int
+		after  string
+	}{
+		{"neg universe", -1, []int{1}, "{}"},
+		{"zero universe", 0, []int{1}, "{}"},
+		{"empty receiver", 10, nil, "{0..9}"},
+		{"empty receiver word boundary", 64, nil, "{0..63}"},
+		{"same word", 5, []int{0, 2}, "{1 3 4}"},
+		{"n smaller than max", 5, []int{1, 100}, "{0 2..4}"},
+		{"n on word boundary", 128, []int{0, 64, 127}, "{1..63 65..126}"},
+		{"n after word boundary", 129, []int{0, 64, 127}, "{1..63 65..126 128}"},
+		{"full", 128, []int{}, "{0..127}"},
+		{"result trimmed", 200, nil, "{0..199}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			got := bs.Complement(tt.n)
+			require.Equal(t, tt.after, got.String())
+			require.True(t, New(tt.before...).Equal(bs))
+		})
+	}
+
+	t.Run("result is trimmed", func(t *testing.T) {
+		bs := New()
+		bs.AddRange(0, 200)
+		got := bs.Complement(128)
+		require.True(t, got.Empty())
+		require.Len(t, got, 0)
+	})
+}
+
+func TestBitSet_ComplementRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		m, n   int
+		before []int
+		after  string
+	}{
+		{"empty range", 5, 5, []int{1}, "{1}"},
+		{"empty receiver", 2, 10, nil, "{2..9}"},
+		{"keeps outside", 2, 6, []int{0, 3, 7}, "{0 2 4 5 7}"},
+		{"across words", 60, 70, []int{1, 64, 100}, "{1 60..63 65..69 100}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			bs.ComplementRange(tt.m, tt.n)
+			require.Equal(t, tt.after, bs.String())
+		})
+	}
+}
+
+func TestBitSet_Shift(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []int
+		k      int
+		left   string
+		right  string
+	}{
+		{"empty", nil, 3, "{}", "{}"},
+		{"zero", []int{1, 64}, 0, "{1 64}", "{1 64}"},
+		{"negative", []int{1, 64}, -5, "{1 64}", "{1 64}"},
+		{"small", []int{0, 1, 5}, 1, "{1 2 6}", "{0 4}"},
+		{"cross 63 64", []int{62, 63, 64}, 1, "{63..65}", "{61..63}"},
+		{"whole word", []int{0, 63, 64}, 64, "{64 127 128}", "{0}"},
+		{"several words", []int{1, 100}, 192, "{193 292}", "{}"},
+		{"words and bits", []int{1, 100, 300}, 70, "{71 170 370}", "{30 230}"},
+		{"drop all", []int{1, 2, 3}, 4, "{5..7}", "{}"},
+		{"drop top word", []int{5, 64}, 10, "{15 74}", "{54}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			bs.ShiftLeft(tt.k)
+			require.Equal(t, tt.left, bs.String())
+			require.True(t, bs.Empty() || bs[len(bs)-1] != 0)
+
+			bs = New(tt.before...)
+			bs.ShiftRight(tt.k)
+			require.Equal(t, tt.right, bs.String())
+			require.True(t, bs.Empty() || bs[len(bs)-1] != 0)
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(3, 4))
+		for range 100 {
+			orig := New()
+			for range 50 {
+				orig.Add(r.IntN(1000))
+			}
+			k := r.IntN(300)
+			left, right := orig.Copy(), orig.Copy()
+			left.ShiftLeft(k)
+			right.ShiftRight(k)
+			for x := range 1400 {
+				require.Equal(t, orig.Contains(x), left.Contains(x+k))
+				require.Equal(t, orig.Contains(x+k), right.Contains(x))
+			}
+			require.Equal(t, orig.Size(), left.Size())
+			require.Equal(t, orig.CountRange(k, 1000), right.Size())
+		}
+	})
+}
+
+func TestBitSet_Grow(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []int
+		n      int
+		cap    int
+	}{
+		{"neg", []int{1}, -1, 1},
+		{"empty", nil, 0, 1},
+		{"within capacity", []int{100}, 127, 2},
+		{"grow empty", nil, 1000, 16},
+		{"grow", []int{1, 100}, 64 * 10, 11},
+		{"grow by pow2", []int{1, 100}, 64 * 3, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(tt.before...)
+			bs.Grow(tt.n)
+			require.Equal(t, tt.cap, cap(bs))
+			require.True(t, bs.Equal(New(tt.before...)))
+		})
+	}
+
+	t.Run("no reallocation after grow", func(t *testing.T) {
+		bs := New()
+		bs.Grow(100_000)
+		allocs := testing.AllocsPerRun(1, func() {
+			for i := range 100_000 {
+				bs.Add(i)
+			}
+		})
+		require.Zero(t, allocs)
+		require.Equal(t, 100_000, bs.Size())
+	})
+}
+
+func TestBitSet_Stats(t *testing.T) {
+	header := int(unsafe.Sizeof(BitSet{}))
+	tests := []struct {
+		name     string
+		bs       func() BitSet
+		byteSize int
+		expect   Stats
+	}{
+		{"empty", func() BitSet { return BitSet{} }, header, Stats{}},
+		{"range", func() BitSet {
+			var b BitSet
+			b.AddRange(0, 576)
+			return b
+		}, 9*8 + header, Stats{
+			WordsUsed: 9, WordsCap: 9, SetBits: 576, Ranges: 1, Density: 1,
+		}},
+		{"sparse", func() BitSet { return New(1, 1_000_000) }, 15626*8 + header, Stats{
+			WordsUsed: 15626, WordsCap: 15626, SetBits: 2, Ranges: 2,
+			Density: 2.0 / (15626 * 64), WastedWords: 15624,
+		}},
+		{"runs across words", func() BitSet {
+			b := make(BitSet, 0, 8)
+			b.AddRange(60, 70)
+			b.AddRange(128, 130)
+			b.Add(191, 192, 256)
+			return b
+		}, 8*8 + header, Stats{
+			WordsUsed: 5, WordsCap: 8, SetBits: 15, Ranges: 4,
+			Density: 15.0 / (5 * 64),
+		}},
+		{"with a zero word", func() BitSet { return New(0, 1, 2, 200) }, 4*8 + header, Stats{
+			WordsUsed: 4, WordsCap: 4, SetBits: 4, Ranges: 2,
+			Density: 4.0 / (4 * 64), WastedWords: 2,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := tt.bs()
+			require.Equal(t, tt.byteSize, bs.ByteSize())
+			st := bs.Stats()
+			require.Equal(t, tt.expect, st)
+			require.Equal(t, bs.Size(), st.SetBits)
+			require.Equal(t, bs.NumRanges(), st.Ranges)
+		})
+	}
+}
+
+func TestBitSet_Clip(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   func() BitSet
+	}{
+		{"empty", func() BitSet { return New() }},
+		{"no excess", func() BitSet { return New(1, 100) }},
+		{"after delete range", func() BitSet {
+			b := New(1)
+			b.AddRange(0, 10000)
+			b.DeleteRange(100, 10000)
+			return b
+		}},
+		{"after reset", func() BitSet {
+			b := New(1000)
+			b.Reset()
+			return b
+		}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			bs := New(tt.before...)
-			bs.DeleteRange(tt.m, tt.n)
-			require.Equal(t, tt.after, bs.String())
+			bs := tt.bs()
+			before := bs.String()
+			bs.Clip()
+			require.Equal(t, len(bs), cap(bs))
+			require.Equal(t, len(bs)*64, bs.Cap())
+			require.Equal(t, before, bs.String())
 		})
 	}
+
+	t.Run("cap", func(t *testing.T) {
+		require.Zero(t, New().Cap())
+		require.Equal(t, 128, New(100).Cap())
+		bs := New()
+		bs.Grow(1000)
+		require.Equal(t, 1024, bs.Cap())
+	})
 }
 
 func TestBitSet_Set(t *testing.T) {
@@ -463,6 +2661,231 @@ func TestBitSet_Set(t *testing.T) {
 			require.True(t, dstCopy.Equal(tt.src))
 		})
 	}
+
+	t.Run("self", func(t *testing.T) {
+		bs := New(1, 100, 200)
+		bs.Set(bs)
+		require.Equal(t, "{1 100 200}", bs.String())
+	})
+
+	t.Run("aliased prefix", func(t *testing.T) {
+		bs := New(1, 100, 200)
+		bs.Set(bs[:2])
+		require.Equal(t, "{1 100}", bs.String())
+		require.Equal(t, 4, cap(bs))
+		require.Zero(t, bs[:3][2], "words beyond the length must be zeroed")
+	})
+
+	t.Run("aliased shifted", func(t *testing.T) {
+		bs := New(1, 100, 200)
+		bs.Set(bs[1:])
+		require.Equal(t, "{36 136}", bs.String())
+	})
+
+	t.Run("allocs", func(t *testing.T) {
+		dst := New(1000)
+		src := New(1, 2, 500)
+		allocs := testing.AllocsPerRun(100, func() {
+			dst.Set(src)
+		})
+		require.Zero(t, allocs)
+		require.True(t, dst.Equal(src))
+	})
+}
+
+func TestBitSet_StaleWords(t *testing.T) {
+	t.Run("reset", func(t *testing.T) {
+		bs := New(1, 100, 200, 300)
+		bs.Reset()
+		bs.Or(New(5))
+		bs.Add(70)
+		bs.AddRange(130, 131)
+		require.Equal(t, "{5 70 130}", bs.String())
+
+		bs.Reset()
+		bs.Xor(New(1, 64))
+		bs.Add(255)
+		require.Equal(t, "{1 64 255}", bs.String())
+	})
+
+	t.Run("re-slice", func(t *testing.T) {
+		bs := New(1, 100, 200, 300)
+		bs = bs[:1] // leaves the words of 100, 200 and 300 beyond the length
+		bs.Add(300)
+		require.Equal(t, "{1 300}", bs.String())
+
+		bs = New(1, 100, 200, 300)[:1]
+		bs.Or(New(2, 70))
+		bs.Xor(New(3, 140))
+		bs.AddRange(250, 251)
+		require.Equal(t, "{1..3 70 140 250}", bs.String())
+	})
+}
+
+// FuzzBitSet_Reuse interleaves operations that reuse the backing array
+// and checks the set against a map.
+func FuzzBitSet_Reuse(f *testing.F) {
+	f.Add([]byte{1, 200, 0, 2, 10})
+	f.Add([]byte{1, 255, 4, 1, 0, 3, 255})
+	f.Add([]byte{3, 100, 3, 100, 1, 30, 4, 2, 255})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		var bs BitSet
+		model := map[int]bool{}
+		for len(ops) >= 2 {
+			op, arg := ops[0]%5, int(ops[1])*3
+			ops = ops[2:]
+			switch op {
+			case 0:
+				bs.Reset()
+				clear(model)
+			case 1:
+				bs.Add(arg)
+				model[arg] = true
+			case 2:
+				bs.Or(New(arg, arg/2))
+				model[arg], model[arg/2] = true, true
+			case 3:
+				bs.Xor(New(arg))
+				model[arg] = !model[arg]
+			case 4:
+				bs = bs[:len(bs)/2] // a re-slice that leaves stale words
+				for n := range model {
+					if n >= len(bs)*bpw {
+						delete(model, n)
+					}
+				}
+			}
+
+			size := 0
+			for n := range 256 * 3 {
+				require.Equal(t, model[n], bs.Contains(n), n)
+				if model[n] {
+					size++
+				}
+			}
+			require.Equal(t, size, bs.Size())
+		}
+	})
+}
+
+func TestBitSet_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+		err  string
+	}{
+		{"nil", nil, ""},
+		{"empty", New(), ""},
+		{"trimmed", New(1, 100), ""},
+		{"trailing zero", BitSet{1, 0}, "bitset: trailing zero word at index 1"},
+		{"only zero", BitSet{0}, "bitset: trailing zero word at index 0"},
+		{"stale word", BitSet{1, 0, 5}[:1], ""},
+		{"zero beyond length", BitSet{1, 0, 0}[:1], ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.bs.Validate()
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+// FuzzOps interprets the input as a sequence of three-byte operations
+// applied both to a set and to a map, and checks that they agree
+// after every step.
+func FuzzOps(f *testing.F) {
+	for _, n := range []byte{63, 64, 65, 127, 128} {
+		f.Add([]byte{0, n, 0})
+		f.Add([]byte{0, n, 0, 1, n, 0})
+		f.Add([]byte{2, n - 1, n + 1, 3, n, n + 1})
+		f.Add([]byte{2, 0, n, 4, n, 255, 5, n - 1, n + 2})
+		f.Add([]byte{0, 255, 0, 6, n, 200, 7, 0, n, 8, 0, 0, 0, n, 0})
+	}
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		var bs BitSet
+		model := map[int]struct{}{}
+		ops = ops[:min(len(ops), 300)] // long inputs add little but slow down fuzzing
+		for ; len(ops) >= 3; ops = ops[3:] {
+			a, b := int(ops[1]), int(ops[2])
+			other := New(a, b)
+			other.AddRange(b, b+a%70)
+			switch ops[0] % 9 {
+			case 0:
+				bs.Add(a)
+				model[a] = struct{}{}
+			case 1:
+				bs.Delete(a)
+				delete(model, a)
+			case 2:
+				bs.AddRange(a, b)
+				for n := a; n < b; n++ {
+					model[n] = struct{}{}
+				}
+			case 3:
+				bs.DeleteRange(a, b)
+				for n := a; n < b; n++ {
+					delete(model, n)
+				}
+			case 4:
+				bs.Or(other)
+				for n := range other.All() {
+					model[n] = struct{}{}
+				}
+			case 5:
+				bs.And(other)
+				for n := range model {
+					if !other.Contains(n) {
+						delete(model, n)
+					}
+				}
+			case 6:
+				bs.Xor(other)
+				for n := range other.All() {
+					if _, ok := model[n]; ok {
+						delete(model, n)
+					} else {
+						model[n] = struct{}{}
+					}
+				}
+			case 7:
+				bs.AndNot(other)
+				for n := range other.All() {
+					delete(model, n)
+				}
+			case 8:
+				bs.Reset()
+				clear(model)
+			}
+
+			require.NoError(t, bs.Validate())
+			elems := make([]int, 0, len(model))
+			for n := range model {
+				elems = append(elems, n)
+			}
+			slices.Sort(elems)
+			for n := range 400 {
+				if _, ok := model[n]; ok != bs.Contains(n) {
+					t.Fatalf("Contains(%d) = %v, want %v", n, !ok, ok)
+				}
+			}
+			require.Equal(t, len(elems), bs.Size())
+			if len(elems) == 0 {
+				require.Equal(t, -1, bs.Min())
+				require.Equal(t, -1, bs.Max())
+			} else {
+				require.Equal(t, elems[0], bs.Min())
+				require.Equal(t, elems[len(elems)-1], bs.Max())
+			}
+			require.Equal(t, New(elems...).String(), bs.String())
+		}
+	})
 }
 
 func TestBitSet_Copy(t *testing.T) {
@@ -475,6 +2898,52 @@ func TestBitSet_Copy(t *testing.T) {
 	require.False(t, cp.Equal(src))
 }
 
+func TestBitSet_CopyTo(t *testing.T) {
+	tests := []struct {
+		name string
+		src  BitSet
+		dst  BitSet
+	}{
+		{"both empty", New(), New()},
+		{"dst nil", New(1, 100), nil},
+		{"dst empty src large", New(100, 200, 300), New()},
+		{"dst small src empty", New(), New(1, 2)},
+		{"dst larger", New(1, 2), New(50, 300, 1000)},
+		{"dst smaller", New(100, 200, 300), New(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.src.CopyTo(&tt.dst)
+			require.True(t, tt.dst.Equal(tt.src))
+			require.Zero(t, tt.dst[:cap(tt.dst)][len(tt.dst):].Size(), "excess words are zeroed")
+
+			tt.dst.Add(5000)
+			require.False(t, tt.dst.Equal(tt.src), "dst does not share storage with src")
+		})
+	}
+
+	t.Run("no allocation with capacity", func(t *testing.T) {
+		src := New(1, 100, 200)
+		dst := New(1000)
+		require.Zero(t, testing.AllocsPerRun(10, func() {
+			src.CopyTo(&dst)
+		}))
+		require.True(t, dst.Equal(src))
+	})
+
+	t.Run("aliasing", func(t *testing.T) {
+		dst := New(1, 64, 128, 192)
+		src := dst[:2]
+		src.CopyTo(&dst)
+		require.Equal(t, "{1 64}", dst.String())
+
+		dst = New(1, 64, 128, 192)
+		dst.CopyTo(&dst)
+		require.Equal(t, "{1 64 128 192}", dst.String())
+	})
+}
+
 func TestAnd(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -545,6 +3014,47 @@ func TestOr(t *testing.T) {
 	}
 }
 
+func TestBitSet_Or(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   BitSet
+		expect string
+	}{
+		{"both empty", New(), New(), "{}"},
+		{"a empty", New(), New(1), "{1}"},
+		{"b empty", New(1), New(), "{1}"},
+		{"same", New(1), New(1), "{1}"},
+		{"partial overlap", New(1, 2), New(2, 3), "{1..3}"},
+		{"grow", New(1, 2), New(2, 300), "{1 2 300}"},
+		{"grow past unroll", New(1), New(1, 64, 640, 1000), "{1 64 640 1000}"},
+		{"grow trailing zero words", New(1), BitSet{0b100, 0, 0}, "{1 2}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.a.Copy()
+			res.Or(tt.b)
+			require.Equal(t, tt.expect, res.String())
+			require.True(t, res.Empty() || res[len(res)-1] != 0)
+		})
+	}
+
+	t.Run("grow within capacity", func(t *testing.T) {
+		other := New(1, 100, 1000)
+		acc := make(BitSet, 0, 64)
+		require.Zero(t, testing.AllocsPerRun(10, func() {
+			acc = acc[:0]
+			acc.Or(other)
+		}))
+		require.Equal(t, "{1 100 1000}", acc.String())
+		require.Zero(t, testing.AllocsPerRun(10, func() {
+			acc = acc[:0]
+			acc.Xor(other)
+		}))
+		require.Equal(t, "{1 100 1000}", acc.String())
+	})
+}
+
 func TestXor(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -628,6 +3138,372 @@ func TestBitset_AndNot(t *testing.T) {
 	}
 }
 
+func TestBitSet_OpRange(t *testing.T) {
+	// Empty windows are no-ops and leave untrimmed operands as they are.
+	requireValid := func(t *testing.T, bs BitSet, m, n int) {
+		t.Helper()
+		if m < n && n > 0 {
+			require.NoError(t, bs.Validate())
+		}
+	}
+
+	sets := []BitSet{
+		New(),
+		New(0, 5, 63),
+		New(1, 64, 65, 127, 128, 300),
+		FromRange(30, 200),
+		BitSet{0b1010, 0, 0}, // untrimmed
+	}
+	windows := [][2]int{
+		{0, 0}, {10, 5}, {-5, 3},
+		{0, 64}, {64, 128}, {0, 192}, // aligned
+		{3, 60}, {60, 70}, {5, 130}, {63, 65}, // misaligned
+		{100, 1000}, {250, 400}, {500, 600}, // beyond the operands
+	}
+
+	for _, a := range sets {
+		for _, b := range sets {
+			for _, w := range windows {
+				m, n := w[0], w[1]
+				t.Run(fmt.Sprintf("%v %v [%d,%d)", a, b, m, n), func(t *testing.T) {
+					window := FromRange(m, n)
+					inside := And(b, window)
+					outside := AndNot(a, window)
+
+					bs := a.Copy()
+					bs.OrRange(b, m, n)
+					require.Equal(t, Or(a, inside).String(), bs.String())
+					requireValid(t, bs, m, n)
+
+					bs = a.Copy()
+					bs.AndNotRange(b, m, n)
+					require.Equal(t, AndNot(a, inside).String(), bs.String())
+					requireValid(t, bs, m, n)
+
+					bs = a.Copy()
+					bs.AndRange(b, m, n)
+					require.Equal(t, Or(outside, And(a, inside)).String(), bs.String())
+					requireValid(t, bs, m, n)
+				})
+			}
+		}
+	}
+}
+
+func TestBitSet_OrAllAndAll(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 8))
+	randomSet := func(n int) BitSet {
+		var bs BitSet
+		for range r.IntN(50) {
+			bs.Add(r.IntN(n))
+		}
+		return bs
+	}
+	for k := range 50 {
+		others := make([]BitSet, k%7)
+		for i := range others {
+			others[i] = randomSet(64 * (1 + k%5))
+		}
+		if k%10 == 3 {
+			others = append(others, BitSet{})
+		}
+		bs := randomSet(400)
+
+		want := bs.Copy()
+		for _, o := range others {
+			want.Or(o)
+		}
+		got := bs.Copy()
+		got.OrAll(others...)
+		require.Equal(t, want, got)
+		require.NoError(t, got.Validate())
+
+		want = bs.Copy()
+		for _, o := range others {
+			want.And(o)
+		}
+		got = bs.Copy()
+		got.AndAll(others...)
+		require.True(t, want.Equal(got), "%v.AndAll(%v)", bs, others)
+		require.NoError(t, got.Validate())
+	}
+}
+
+// TestBinaryOps_Trimmed checks that the binary operations return trimmed
+// results that agree with a map-based reference, even for untrimmed operands.
+func TestBinaryOps_Trimmed(t *testing.T) {
+	r := rand.New(rand.NewPCG(15, 16))
+	randomWords := func() BitSet {
+		s := make(BitSet, r.IntN(6))
+		for i := range s {
+			switch r.IntN(4) {
+			case 0: // leave zero
+			case 1:
+				s[i] = 1 << r.IntN(bpw)
+			default:
+				s[i] = r.Uint64()
+			}
+		}
+		return s
+	}
+	elems := func(bs BitSet) map[int]bool {
+		m := map[int]bool{}
+		bs.VisitAll(func(n int) { m[n] = true })
+		return m
+	}
+	ops := []struct {
+		name    string
+		fn      func(a, b BitSet) BitSet
+		to      func(dst *BitSet, a, b BitSet)
+		inPlace func(bs *BitSet, other BitSet)
+		ref     func(a, b bool) bool
+	}{
+		{"and", And, AndTo, (*BitSet).And, func(a, b bool) bool { return a && b }},
+		{"or", Or, OrTo, (*BitSet).Or, func(a, b bool) bool { return a || b }},
+		{"xor", Xor, XorTo, (*BitSet).Xor, func(a, b bool) bool { return a != b }},
+		{"andNot", AndNot, AndNotTo, (*BitSet).AndNot, func(a, b bool) bool { return a && !b }},
+	}
+
+	for range 500 {
+		a, b := randomWords(), randomWords()
+		if r.IntN(3) == 0 {
+			b = append(a[:len(a):len(a)], b...) // share the low words
+		}
+		ma, mb := elems(a), elems(b)
+		for _, op := range ops {
+			want := map[int]bool{}
+			for n := range len(a)*bpw + len(b)*bpw {
+				if op.ref(ma[n], mb[n]) {
+					want[n] = true
+				}
+			}
+
+			var dst BitSet
+			op.to(&dst, a, b)
+			inPlace := a.Copy()
+			op.inPlace(&inPlace, b)
+			for _, res := range []BitSet{op.fn(a, b), dst, inPlace} {
+				require.True(t, len(res) == 0 || res[len(res)-1] != 0,
+					"%s(%x, %x) = %x is untrimmed", op.name, a, b, res)
+				require.Equal(t, want, elems(res), "%s(%x, %x)", op.name, a, b)
+			}
+		}
+	}
+
+	require.Equal(t, "{5}", Xor(New(5, 100), New(100)).String())
+	require.Len(t, Xor(New(5, 100), New(100)), 1)
+	require.Len(t, AndNot(New(5, 100), New(100)), 1)
+}
+
+func TestBitSet_Changed(t *testing.T) {
+	bs := New(1, 2)
+	other := New(2, 3, 200)
+	require.True(t, bs.OrChanged(other))
+	require.False(t, bs.OrChanged(other))
+	require.False(t, bs.OrChanged(nil))
+	require.Equal(t, "{1..3 200}", bs.String())
+	require.True(t, bs.AndNotChanged(New(200)))
+	require.False(t, bs.AndNotChanged(New(200)))
+	require.Equal(t, "{1..3}", bs.String())
+	require.True(t, bs.AndChanged(New(2, 3)))
+	require.False(t, bs.AndChanged(New(2, 3)))
+	require.Equal(t, "{2 3}", bs.String())
+	require.True(t, bs.AndChanged(nil))
+	require.True(t, bs.Empty())
+	require.False(t, bs.OrChanged(BitSet{0, 0}))
+
+	r := rand.New(rand.NewPCG(17, 18))
+	randomSet := func() BitSet {
+		var bs BitSet
+		for range r.IntN(30) {
+			bs.Add(r.IntN(400))
+		}
+		if r.IntN(4) == 0 {
+			bs = append(bs, 0) // untrimmed operand
+		}
+		return bs
+	}
+	ops := []struct {
+		name    string
+		changed func(bs *BitSet, other BitSet) bool
+		plain   func(bs *BitSet, other BitSet)
+	}{
+		{"or", (*BitSet).OrChanged, (*BitSet).Or},
+		{"and", (*BitSet).AndChanged, (*BitSet).And},
+		{"andNot", (*BitSet).AndNotChanged, (*BitSet).AndNot},
+	}
+	for range 500 {
+		a, b := randomSet(), randomSet()
+		for _, op := range ops {
+			got, want := a.Copy(), a.Copy()
+			changed := op.changed(&got, b)
+			op.plain(&want, b)
+			require.Equal(t, want, got, "%s(%v, %v)", op.name, a, b)
+			require.Equal(t, !want.Equal(a), changed, "%s(%v, %v)", op.name, a, b)
+			require.False(t, op.changed(&got, b), "%s(%v, %v) again", op.name, a, b)
+		}
+	}
+}
+
+func TestUnionIntersection(t *testing.T) {
+	tests := []struct {
+		name         string
+		sets         []BitSet
+		union        string
+		intersection string
+	}{
+		{"no sets", nil, "{}", "{}"},
+		{"single set", []BitSet{New(1, 64)}, "{1 64}", "{1 64}"},
+		{"one empty", []BitSet{New(1, 2), New(), New(2)}, "{1 2}", "{}"},
+		{"overlapping", []BitSet{New(1, 2, 3), New(2, 3, 4), New(3, 4, 5)}, "{1..5}", "{3}"},
+		{"different length", []BitSet{New(1, 300), New(1, 64), New(1, 2, 1000)}, "{1 2 64 300 1000}", "{1}"},
+		{"disjoint high words", []BitSet{New(1, 300), New(2, 300), New(3, 200)}, "{1..3 200 300}", "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			union := Union(tt.sets...)
+			require.Equal(t, tt.union, union.String())
+			require.True(t, union.Empty() || union[len(union)-1] != 0)
+
+			intersection := Intersection(tt.sets...)
+			require.Equal(t, tt.intersection, intersection.String())
+			require.True(t, intersection.Empty() || intersection[len(intersection)-1] != 0)
+
+			if len(tt.sets) > 0 {
+				expect := tt.sets[0].Copy()
+				for _, s := range tt.sets[1:] {
+					expect.Or(s)
+				}
+				require.True(t, union.Equal(expect))
+			}
+		})
+	}
+}
+
+func TestSetSizes(t *testing.T) {
+	sets := []BitSet{
+		nil,
+		New(),
+		New(1),
+		New(1, 2, 3, 64),
+		New(0, 63, 64, 127, 128, 1000),
+		FromRange(50, 300),
+		BitSet{0b110, 0, 0}, // untrimmed
+	}
+
+	for _, a := range sets {
+		for _, b := range sets {
+			t.Run(a.String()+" "+b.String(), func(t *testing.T) {
+				require.Equal(t, And(a, b).Size(), IntersectionSize(a, b))
+				require.Equal(t, Or(a, b).Size(), UnionSize(a, b))
+				require.Equal(t, AndNot(a, b).Size(), DifferenceSize(a, b))
+				require.Equal(t, Xor(a, b).Size(), SymmetricDifferenceSize(a, b))
+				require.Equal(t, UnionSize(a, b), IntersectionSize(a, b)+SymmetricDifferenceSize(a, b))
+			})
+		}
+	}
+
+	t.Run("allocs", func(t *testing.T) {
+		a, b := FromRange(0, 1000), FromRange(500, 2000)
+		allocs := testing.AllocsPerRun(100, func() {
+			IntersectionSize(a, b)
+			UnionSize(a, b)
+			DifferenceSize(a, b)
+			SymmetricDifferenceSize(a, b)
+		})
+		require.Zero(t, allocs)
+	})
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    BitSet
+		jaccard float64
+		overlap float64
+	}{
+		{"both empty", New(), nil, 1, 1},
+		{"one empty", New(1), New(), 0, 0},
+		{"identical", New(1, 64, 200), New(1, 64, 200), 1, 1},
+		{"disjoint", New(1, 2), New(64, 65, 128), 0, 0},
+		{"subset", New(63, 64), New(62, 63, 64, 65), 0.5, 1},
+		{"across words", FromRange(60, 70), FromRange(64, 130), 6.0 / 70, 0.6},
+		{"untrimmed", BitSet{0b11, 0, 0}, New(1, 2), 1.0 / 3, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.InDelta(t, tt.jaccard, Jaccard(tt.a, tt.b), 1e-12)
+			require.InDelta(t, tt.jaccard, Jaccard(tt.b, tt.a), 1e-12)
+			require.InDelta(t, tt.overlap, OverlapCoefficient(tt.a, tt.b), 1e-12)
+			require.InDelta(t, tt.overlap, OverlapCoefficient(tt.b, tt.a), 1e-12)
+		})
+	}
+}
+
+func TestOpTo(t *testing.T) {
+	ops := []struct {
+		name string
+		to   func(dst *BitSet, s1, s2 BitSet)
+		op   func(s1, s2 BitSet) BitSet
+	}{
+		{"and", AndTo, And},
+		{"or", OrTo, Or},
+		{"xor", XorTo, Xor},
+		{"and not", AndNotTo, AndNot},
+	}
+	tests := []struct {
+		name string
+		a, b BitSet
+	}{
+		{"both empty", New(), New()},
+		{"a empty", New(), New(1)},
+		{"b empty", New(1), New()},
+		{"same", New(1, 64), New(1, 64)},
+		{"partial overlap", New(1, 2), New(2, 3)},
+		{"different length", New(1, 100, 200), New(1, 2, 63)},
+		{"boundary", New(63, 64), New(64, 65, 300)},
+	}
+
+	for _, op := range ops {
+		for _, tt := range tests {
+			t.Run(op.name+" "+tt.name, func(t *testing.T) {
+				expect := op.op(tt.a, tt.b)
+
+				var dst BitSet
+				op.to(&dst, tt.a, tt.b)
+				require.Equal(t, expect.String(), dst.String())
+				require.True(t, dst.Empty() || dst[len(dst)-1] != 0, "result is trimmed")
+
+				dst = New(1000, 2000)
+				op.to(&dst, tt.a, tt.b)
+				require.Equal(t, expect.String(), dst.String())
+				require.True(t, dst.Empty() || dst[len(dst)-1] != 0, "result is trimmed")
+				require.Zero(t, dst[:cap(dst)][len(dst):].Size(), "leftover words are zeroed")
+
+				a := tt.a.Copy()
+				op.to(&a, a, tt.b)
+				require.Equal(t, expect.String(), a.String(), "dst aliases s1")
+
+				b := tt.b.Copy()
+				op.to(&b, tt.a, b)
+				require.Equal(t, expect.String(), b.String(), "dst aliases s2")
+			})
+		}
+	}
+
+	t.Run("no allocation with capacity", func(t *testing.T) {
+		a, b := New(1, 100, 200), New(1, 2, 300)
+		dst := make(BitSet, 0, 8)
+		for _, op := range ops {
+			require.Zero(t, testing.AllocsPerRun(10, func() {
+				op.to(&dst, a, b)
+			}))
+		}
+	})
+}
+
 func TestNextPow2(t *testing.T) {
 	tests := []struct {
 		n, expected int
@@ -676,6 +3552,198 @@ func TestBitSet_String(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			got := tt.bs.String()
 			require.Equal(t, tt.expect, got)
+			require.Equal(t, "x="+tt.expect, string(tt.bs.AppendString([]byte("x="))))
+		})
+	}
+}
+
+func TestBitSet_Summary(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect string
+	}{
+		{"empty", New(), "BitSet{empty}"},
+		{"untrimmed empty", BitSet{0, 0}, "BitSet{empty}"},
+		{"single", New(5), "BitSet{size=1 min=5 max=5 ranges=1 density=100.0%}"},
+		{"range", FromRange(64, 192), "BitSet{size=128 min=64 max=191 ranges=1 density=100.0%}"},
+		{"sparse", New(3, 1_000_002), "BitSet{size=2 min=3 max=1000002 ranges=2 density=0.0%}"},
+		{"runs", New(0, 1, 2, 63, 64, 100, 200, 201), "BitSet{size=8 min=0 max=201 ranges=4 density=4.0%}"},
+		{"untrimmed", BitSet{0, 0b110, 0}, "BitSet{size=2 min=65 max=66 ranges=1 density=100.0%}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs.Summary())
+			if tt.bs.Empty() {
+				return
+			}
+			density := 100 * float64(tt.bs.Size()) / float64(tt.bs.Max()-tt.bs.Min()+1)
+			require.Equal(t, fmt.Sprintf("BitSet{size=%d min=%d max=%d ranges=%d density=%.1f%%}",
+				tt.bs.Size(), tt.bs.Min(), tt.bs.Max(), tt.bs.NumRanges(), density), tt.bs.Summary())
+		})
+	}
+}
+
+func TestBitSet_StringMaxRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		bs      BitSet
+		maxRuns int
+		expect  string
+	}{
+		{"empty", New(), 3, "{}"},
+		{"empty zero runs", New(), 0, "{}"},
+		{"all runs", New(0, 1, 2, 5), -1, "{0..2 5}"},
+		{"exactly max", New(0, 1, 2, 5), 2, "{0..2 5}"},
+		{"more than runs", New(0, 1, 2, 5), 10, "{0..2 5}"},
+		{"truncated", New(0, 1, 2, 5, 7, 8, 100), 2, "{0..2 5 ... +3 more}"},
+		{"zero runs", New(0, 1, 2, 5), 0, "{... +4 more}"},
+		{"long runs", Union(FromRange(0, 10), FromRange(20, 21), FromRange(30, 411)), 2, "{0..9 20 ... +381 more}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.bs.StringMaxRuns(tt.maxRuns))
+		})
+	}
+
+	t.Run("suffix count", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(5, 6))
+		bs := New()
+		for range 1000 {
+			bs.Add(r.IntN(100_000))
+		}
+		for _, maxRuns := range []int{0, 1, 10, 100} {
+			s := bs.StringMaxRuns(maxRuns)
+			shown, err := ParseString(s[:strings.Index(s, "...")] + "}")
+			require.NoError(t, err)
+			require.Equal(t, maxRuns, shown.NumRanges())
+			require.True(t, strings.HasSuffix(s, fmt.Sprintf(" +%d more}", bs.Size()-shown.Size())), s)
+		}
+		require.Equal(t, bs.String(), bs.StringMaxRuns(bs.NumRanges()))
+	})
+}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestBitSet_GoString(t *testing.T) {
+	scattered := New()
+	for n := range 200 {
+		scattered.Add(n * 3)
+	}
+	tests := []struct {
+		name string
+		bs   BitSet
+	}{
+		{"empty", New()},
+		{"small", New(1, 2, 128)},
+		{"single range", FromRange(0, 576)},
+		{"mixed", Union(New(1, 3, 5, 6, 7), FromRange(64, 576), FromRange(1000, 1010))},
+		{"elided", scattered},
+		{"elided ranges", Union(scattered, FromRange(1000, 2000))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.bs.GoString()
+			require.Equal(t, got, fmt.Sprintf("%#v", tt.bs))
+
+			golden := filepath.Join("testdata", "gostring", strings.ReplaceAll(tt.name, " ", "_")+".golden")
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+				require.NoError(t, os.WriteFile(golden, []byte(got+"\n"), 0o644))
+			}
+			expect, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), got+"\n")
+
+			expr, err := parser.ParseExpr(got)
+			require.NoError(t, err)
+			rebuilt := evalGoString(t, expr)
+			if _, rest, ok := strings.Cut(got, "/* +"); ok {
+				var elided int
+				_, err := fmt.Sscanf(rest, "%d more elements */", &elided)
+				require.NoError(t, err)
+				require.True(t, rebuilt.Subset(tt.bs))
+				require.Equal(t, tt.bs.Size(), rebuilt.Size()+elided)
+				return
+			}
+			require.True(t, rebuilt.Equal(tt.bs), rebuilt.String())
+		})
+	}
+}
+
+// evalGoString evaluates the output of GoString, which only consists
+// of bitset.New, bitset.FromRange and bitset.Union calls.
+func evalGoString(t *testing.T, expr ast.Expr) BitSet {
+	call, ok := expr.(*ast.CallExpr)
+	require.True(t, ok, "not a call")
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	require.True(t, ok, "not a selector")
+	require.Equal(t, "bitset", sel.X.(*ast.Ident).Name)
+	ints := func() []int {
+		var n []int
+		for _, arg := range call.Args {
+			lit, ok := arg.(*ast.BasicLit)
+			require.True(t, ok, "not a literal")
+			v, err := strconv.Atoi(lit.Value)
+			require.NoError(t, err)
+			n = append(n, v)
+		}
+		return n
+	}
+	switch sel.Sel.Name {
+	case "New":
+		return New(ints()...)
+	case "FromRange":
+		n := ints()
+		require.Len(t, n, 2)
+		return FromRange(n[0], n[1])
+	case "Union":
+		var sets []BitSet
+		for _, arg := range call.Args {
+			sets = append(sets, evalGoString(t, arg))
+		}
+		return Union(sets...)
+	}
+	t.Fatalf("unexpected function %s", sel.Sel.Name)
+	return nil
+}
+
+func TestBitSet_Format(t *testing.T) {
+	multi := New(0, 2, 3, 64, 65, 130)
+	tests := []struct {
+		name   string
+		format string
+		bs     BitSet
+		expect string
+	}{
+		{"v empty", "%v", New(), "{}"},
+		{"v single word", "%v", New(0, 2, 3), "{0 2 3}"},
+		{"v multi word", "%v", multi, "{0 2 3 64 65 130}"},
+		{"s multi word", "%s", multi, "{0 2 3 64 65 130}"},
+		{"v width", "%8v", New(1), "     {1}"},
+		{"#v empty", "%#v", New(), "bitset.New()"},
+		{"#v single word", "%#v", New(0, 2, 3), "bitset.New(0, 2, 3)"},
+		{"#v multi word", "%#v", multi, "bitset.New(0, 2, 3, 64, 65, 130)"},
+		{"d empty", "%d", New(), ""},
+		{"d single word", "%d", New(0, 1, 2, 3), "0 1 2 3"},
+		{"d multi word", "%d", multi, "0 2 3 64 65 130"},
+		{"d width", "%3d", New(1, 64), "  1  64"},
+		{"b empty", "%b", New(), ""},
+		{"b single word", "%b", New(0, 2, 3), "1011"},
+		{"b multi word", "%b", New(1, 64), "01" + fmt.Sprintf("%062d", 0) + "1"},
+		{"x empty", "%x", New(), "[]"},
+		{"x single word", "%x", New(0, 2, 3), "[d]"},
+		{"x multi word", "%x", multi, "[d 3 4]"},
+		{"X multi word", "%X", New(0, 2, 3, 7, 64), "[8D 1]"},
+		{"unknown verb", "%z", New(1), "%!z(bitset.BitSet={1})"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, fmt.Sprintf(tt.format, tt.bs))
 		})
 	}
 }
@@ -0,0 +1,100 @@
+package bitset
+
+// builderBatch is the number of elements that Builder.Add buffers
+// before writing them to the set.
+const builderBatch = 256
+
+// Builder constructs sets incrementally. The elements passed to Add are
+// buffered and written in batches: the storage is sized once per batch
+// for the largest element of the batch, and the set isn't trimmed until
+// Build. It keeps its storage between builds, so a reused Builder
+// allocates only the final set in Build. The zero value of Builder
+// is ready to use.
+type Builder struct {
+	bs      BitSet
+	pending []int // the elements added since the last flush, all ≥ 0
+	top     int   // the largest pending element
+}
+
+// grow makes b hold the elements up to n.
+func (b *Builder) grow(n int) {
+	if i := n >> shift; i >= len(b.bs) {
+		b.bs.resize(i + 1)
+	}
+}
+
+// flush writes the pending elements to b.bs.
+func (b *Builder) flush() {
+	if len(b.pending) == 0 {
+		return
+	}
+	b.grow(b.top)
+	s := b.bs
+	for _, e := range b.pending {
+		s[e>>shift] |= 1 << uint(e&div64rem)
+	}
+	b.pending, b.top = b.pending[:0], 0
+}
+
+// Add adds n to the set being built, skipping it if negative.
+// It panics if n is greater than MaxElement.
+func (b *Builder) Add(n int) {
+	if n < 0 {
+		return
+	}
+	mustFit(n)
+	if b.pending == nil {
+		b.pending = make([]int, 0, builderBatch)
+	}
+	b.pending = append(b.pending, n)
+	b.top = max(b.top, n)
+	if len(b.pending) == builderBatch {
+		b.flush()
+	}
+}
+
+// AddRange adds all integers from m to n-1 to the set being built
+// (no-op if m>=n). It panics if n-1 is greater than MaxElement.
+func (b *Builder) AddRange(m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	mustFit(n - 1)
+	b.grow(n - 1)
+	b.bs.AddRange(m, n)
+}
+
+// AddSlice adds the elements of n to the set being built, skipping negative
+// ones. The storage is sized once for the largest element of n. AddSlice
+// panics if an element is greater than MaxElement.
+func (b *Builder) AddSlice(n []int) {
+	top := -1
+	for _, e := range n {
+		top = max(top, e)
+	}
+	if top < 0 {
+		return
+	}
+	mustFit(top)
+	b.grow(top)
+	s := b.bs
+	for _, e := range n {
+		if e >= 0 {
+			s[e>>shift] |= 1 << uint(e&div64rem)
+		}
+	}
+}
+
+// Build returns a new set with the elements added since the last Reset.
+// The returned set has no spare capacity and doesn't share storage
+// with b, so b can keep being used.
+func (b *Builder) Build() BitSet {
+	b.flush()
+	return b.bs[:b.bs.trimmedLen()].Copy()
+}
+
+// Reset empties the set being built, keeping the allocated storage.
+func (b *Builder) Reset() {
+	b.bs = b.bs[:0]
+	b.pending, b.top = b.pending[:0], 0
+}
@@ -0,0 +1,77 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+	b.Add(5)
+	b.Add(-1)
+	b.AddRange(100, 130)
+	b.AddRange(-5, 2)
+	b.AddRange(10, 10)
+	b.AddSlice([]int{700, 3, -2})
+
+	bs := b.Build()
+	require.Equal(t, "{0 1 3 5 100..129 700}", bs.String())
+	require.Equal(t, len(bs), cap(bs))
+
+	b.Add(900)
+	require.False(t, bs.Contains(900), "Build must not share storage")
+
+	capacity := cap(b.bs)
+	b.Reset()
+	require.Equal(t, capacity, cap(b.bs))
+	require.Equal(t, "{}", b.Build().String())
+
+	b.Add(1)
+	require.Equal(t, "{1}", b.Build().String(), "Reset must clear the storage")
+
+	b.Add(7)
+	b.Reset()
+	require.Equal(t, "{}", b.Build().String(), "Reset must drop the pending elements")
+	b.AddSlice([]int{-1, -5})
+	b.AddSlice(nil)
+	require.Equal(t, "{}", b.Build().String())
+
+	// The elements of Add are buffered; Build writes the pending ones.
+	var want BitSet
+	for i := range 3*builderBatch + 7 {
+		b.Add(i * 5)
+		want.Add(i * 5)
+	}
+	require.Equal(t, want, b.Build())
+	require.Equal(t, want, b.Build())
+
+	var empty Builder
+	require.True(t, empty.Build().Empty())
+}
+
+func TestBuilder_Random(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	var b Builder
+	for range 10 {
+		b.Reset()
+		var direct BitSet
+		for range 1000 {
+			switch n := r.IntN(5000); r.IntN(3) {
+			case 0:
+				b.Add(n)
+				direct.Add(n)
+			case 1:
+				m := n + r.IntN(100)
+				b.AddRange(n, m)
+				direct.AddRange(n, m)
+			default:
+				s := []int{n, r.IntN(5000)}
+				b.AddSlice(s)
+				direct.Add(s...)
+			}
+		}
+		require.True(t, b.Build().Equal(direct))
+	}
+}
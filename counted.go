@@ -0,0 +1,244 @@
+package bitset
+
+import "math/bits"
+
+// Counted is a set that maintains its cardinality, minimum and maximum
+// incrementally, so that Size, Min and Max are O(1). Every mutation updates
+// the count by the change in the population count of the words it touches.
+// The extrema are updated in place by the element and range methods,
+// which only rescan the set for a new extremum when the current one is
+// removed; the operations with another set rescan both. The zero value
+// of Counted is an empty set.
+type Counted struct {
+	bs       BitSet
+	size     int
+	min, max int // valid only if size > 0
+}
+
+// NewCounted creates a new counted set with the given non-negative elements.
+func NewCounted(n ...int) Counted {
+	var c Counted
+	c.Add(n...)
+	return c
+}
+
+// CountedFrom creates a new counted set with the elements of bs.
+// Later changes to bs don't affect the returned set.
+func CountedFrom(bs BitSet) Counted {
+	c := Counted{bs: bs[:bs.trimmedLen()].Copy(), size: bs.Size()}
+	c.rescan()
+	return c
+}
+
+// rescan recomputes the extrema of c from its words.
+func (c *Counted) rescan() {
+	c.min, c.max = c.bs.Min(), c.bs.Max()
+}
+
+// Size returns the number of elements in c in constant time.
+func (c Counted) Size() int {
+	return c.size
+}
+
+// Empty tells if c is empty.
+func (c Counted) Empty() bool {
+	return c.size == 0
+}
+
+// Contains tells if n is in c.
+func (c Counted) Contains(n int) bool {
+	return c.bs.Contains(n)
+}
+
+// Min returns the minimum element of c in constant time,
+// or -1 if c is empty.
+func (c Counted) Min() int {
+	if c.size == 0 {
+		return -1
+	}
+	return c.min
+}
+
+// Max returns the maximum element of c in constant time,
+// or -1 if c is empty.
+func (c Counted) Max() int {
+	if c.size == 0 {
+		return -1
+	}
+	return c.max
+}
+
+// Equal tells if c and other contain the same elements.
+func (c Counted) Equal(other Counted) bool {
+	return c.size == other.size && c.bs.Equal(other.bs)
+}
+
+// Visit calls do for each element of c in ascending order like BitSet.Visit.
+func (c Counted) Visit(do func(n int) bool) (aborted bool) {
+	return c.bs.Visit(do)
+}
+
+// BitSet returns a copy of the elements of c as a BitSet.
+func (c Counted) BitSet() BitSet {
+	return c.bs.Copy()
+}
+
+// String returns a string representation of c like BitSet.String.
+func (c Counted) String() string {
+	return c.bs.String()
+}
+
+// Reset removes all elements from c, keeping the allocated storage.
+func (c *Counted) Reset() {
+	c.bs.Reset()
+	c.size = 0
+}
+
+// Add adds the given elements to c, skipping negative ones.
+// It panics if an element is greater than MaxElement.
+func (c *Counted) Add(n ...int) {
+	for _, e := range n {
+		if e < 0 {
+			continue
+		}
+		mustFit(e)
+		if c.bs.TestAndSet(e) {
+			continue
+		}
+		if c.size == 0 {
+			c.min, c.max = e, e
+		} else {
+			c.min, c.max = min(c.min, e), max(c.max, e)
+		}
+		c.size++
+	}
+}
+
+// Delete removes the given elements from c.
+func (c *Counted) Delete(n ...int) {
+	for _, e := range n {
+		if !c.bs.TestAndClear(e) {
+			continue
+		}
+		c.size--
+		if e == c.min {
+			c.min = c.bs.Next(e)
+		}
+		if e == c.max {
+			c.max = c.bs.Max()
+		}
+	}
+}
+
+// PopMin removes the minimum element from c and returns it.
+// If c is empty, -1 is returned.
+func (c *Counted) PopMin() int {
+	if c.size == 0 {
+		return -1
+	}
+	n := c.min
+	c.Delete(n)
+	return n
+}
+
+// PopMax removes the maximum element from c and returns it.
+// If c is empty, -1 is returned.
+func (c *Counted) PopMax() int {
+	if c.size == 0 {
+		return -1
+	}
+	n := c.max
+	c.Delete(n)
+	return n
+}
+
+// AddRange adds all integers from m to n-1 to c (no-op if m>=n).
+func (c *Counted) AddRange(m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	before := c.bs.CountRange(m, n)
+	c.bs.AddRange(m, n)
+	m = max(0, m)
+	if c.size == 0 {
+		c.min, c.max = m, n-1
+	} else {
+		c.min, c.max = min(c.min, m), max(c.max, n-1)
+	}
+	c.size += n - m - before
+}
+
+// DeleteRange removes all integers from m to n-1 from c (no-op if m>=n).
+func (c *Counted) DeleteRange(m, n int) {
+	deleted := c.bs.CountRange(m, n)
+	if deleted == 0 {
+		return
+	}
+	c.bs.DeleteRange(m, n)
+	c.size -= deleted
+	if c.min >= m && c.min < n {
+		c.min = c.bs.Next(n - 1)
+	}
+	if c.max >= m && c.max < n {
+		c.max = c.bs.Max()
+	}
+}
+
+// Or adds the elements of other to c.
+func (c *Counted) Or(other BitSet) {
+	other = other[:other.trimmedLen()]
+	if len(other) > len(c.bs) {
+		c.bs.resize(len(other))
+	}
+	s := c.bs
+	for i, o := range other {
+		w := s[i]
+		s[i] = w | o
+		c.size += bits.OnesCount64(o &^ w)
+	}
+	c.rescan()
+}
+
+// And removes the elements of c that are not in other.
+func (c *Counted) And(other BitSet) {
+	s := c.bs
+	for i, w := range s {
+		if i < len(other) {
+			s[i] = w & other[i]
+			c.size -= bits.OnesCount64(w &^ other[i])
+		} else {
+			s[i] = 0
+			c.size -= bits.OnesCount64(w)
+		}
+	}
+	c.bs.trim()
+	c.rescan()
+}
+
+// Xor replaces c by the elements that are in either c or other but not both.
+func (c *Counted) Xor(other BitSet) {
+	other = other[:other.trimmedLen()]
+	if len(other) > len(c.bs) {
+		c.bs.resize(len(other))
+	}
+	s := c.bs
+	for i, o := range other {
+		w := s[i]
+		s[i] = w ^ o
+		c.size += bits.OnesCount64(o&^w) - bits.OnesCount64(o&w)
+	}
+	c.bs.trim()
+	c.rescan()
+}
+
+// AndNot removes the elements of other from c.
+func (c *Counted) AndNot(other BitSet) {
+	s := c.bs
+	for i := range min(len(s), len(other)) {
+		w := s[i]
+		s[i] = w &^ other[i]
+		c.size -= bits.OnesCount64(w & other[i])
+	}
+	c.bs.trim()
+	c.rescan()
+}
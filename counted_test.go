@@ -0,0 +1,97 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounted(t *testing.T) {
+	c := NewCounted(1, 1, -5, 64, 300)
+	require.Equal(t, 3, c.Size())
+	require.Equal(t, "{1 64 300}", c.String())
+	require.Equal(t, 1, c.Min())
+	require.Equal(t, 300, c.Max())
+	require.True(t, c.Contains(64))
+
+	bs := New(1, 2, 3)
+	c = CountedFrom(append(bs, 0))
+	bs.Add(100)
+	require.Equal(t, 3, c.Size())
+	require.Equal(t, "{1..3}", c.String())
+
+	require.Equal(t, 1, c.PopMin())
+	require.Equal(t, 3, c.PopMax())
+	require.Equal(t, 2, c.Min())
+	require.Equal(t, 2, c.Max())
+	require.Equal(t, 2, c.PopMin())
+	require.Equal(t, -1, c.PopMin())
+	require.Equal(t, -1, c.PopMax())
+	require.Equal(t, -1, c.Min())
+	require.Equal(t, -1, c.Max())
+
+	c.AddRange(-5, 10)
+	c.Reset()
+	require.True(t, c.Empty())
+	require.Equal(t, -1, c.Min())
+	require.Zero(t, c.Size())
+	require.True(t, c.Equal(Counted{}))
+}
+
+func TestCounted_Equivalence(t *testing.T) {
+	r := rand.New(rand.NewPCG(11, 12))
+	randomSet := func() BitSet {
+		var bs BitSet
+		for range r.IntN(60) {
+			bs.Add(r.IntN(700))
+		}
+		return bs
+	}
+
+	for range 50 {
+		var c Counted
+		var bs BitSet
+		for range 100 {
+			m := r.IntN(800) - 50
+			n := m + r.IntN(300) - 20
+			other := randomSet()
+			switch r.IntN(10) {
+			case 0:
+				c.Add(m, n)
+				bs.Add(m, n)
+			case 1:
+				c.Delete(m, n, bs.Max())
+				bs.Delete(m, n, bs.Max())
+			case 2:
+				c.AddRange(m, n)
+				bs.AddRange(m, n)
+			case 3:
+				c.DeleteRange(m, n)
+				bs.DeleteRange(m, n)
+			case 4:
+				c.Or(other)
+				bs.Or(other)
+			case 5:
+				c.And(append(other, other...)) // larger operand
+				bs.And(append(other, other...))
+			case 6:
+				c.Xor(other)
+				bs.Xor(other)
+			case 7:
+				c.AndNot(other)
+				bs.AndNot(other)
+			case 8:
+				require.Equal(t, bs.PopMin(), c.PopMin())
+			case 9:
+				require.Equal(t, bs.PopMax(), c.PopMax())
+			}
+			require.Equal(t, bs.Size(), c.Size())
+			require.Equal(t, bs.Min(), c.Min())
+			require.Equal(t, bs.Max(), c.Max())
+			require.True(t, bs.Equal(c.BitSet()))
+			require.Equal(t, bs.Empty(), c.Empty())
+			require.NoError(t, c.bs.Validate())
+		}
+	}
+}
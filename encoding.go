@@ -0,0 +1,622 @@
+package bitset
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// binaryVersion is the version of the binary encoding produced by MarshalBinary.
+const binaryVersion = 1
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The encoding consists of a version byte, the number of words
+// as an unsigned varint and the words in little-endian order.
+func (bs BitSet) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(bs)*8)
+	buf = append(buf, binaryVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(bs)))
+	for _, w := range bs {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It replaces the contents of *bs with the set encoded by MarshalBinary.
+func (bs *BitSet) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("bitset: missing binary version")
+	}
+	if data[0] != binaryVersion {
+		return fmt.Errorf("bitset: unsupported binary version %d", data[0])
+	}
+	data = data[1:]
+	n, k := binary.Uvarint(data)
+	if k <= 0 {
+		return errors.New("bitset: invalid binary word count")
+	}
+	data = data[k:]
+	if n != uint64(len(data)/8) || len(data)%8 != 0 {
+		return fmt.Errorf("bitset: binary data holds %d bytes, expected %d words", len(data), n)
+	}
+	s := make(BitSet, n)
+	for i := range s {
+		s[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+	s.trim()
+	*bs = s
+	return nil
+}
+
+// GobEncode implements the gob.GobEncoder interface
+// with the encoding produced by MarshalBinary.
+func (bs BitSet) GobEncode() ([]byte, error) {
+	return bs.MarshalBinary()
+}
+
+// GobDecode implements the gob.GobDecoder interface. It accepts the encoding
+// produced by GobEncode, and an empty payload as the empty set.
+func (bs *BitSet) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		bs.Reset()
+		return nil
+	}
+	return bs.UnmarshalBinary(data)
+}
+
+// streamChunkWords is the number of words buffered at once by WriteTo and ReadFrom.
+const streamChunkWords = 1024
+
+// WriteTo implements the io.WriterTo interface.
+// It writes the same encoding as MarshalBinary without materializing it
+// in memory, and returns the number of bytes written.
+func (bs BitSet) WriteTo(w io.Writer) (int64, error) {
+	buf := make([]byte, 0, min(len(bs), streamChunkWords)*8+1+binary.MaxVarintLen64)
+	buf = append(buf, binaryVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(bs)))
+	var total int64
+	for i := 0; ; {
+		for ; i < len(bs) && len(buf)+8 <= cap(buf); i++ {
+			buf = binary.LittleEndian.AppendUint64(buf, bs[i])
+		}
+		n, err := w.Write(buf)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		if i == len(bs) {
+			return total, nil
+		}
+		buf = buf[:0]
+	}
+}
+
+// ReadFrom implements the io.ReaderFrom interface.
+// It replaces the contents of *bs with the set read from the encoding written
+// by WriteTo, and returns the number of bytes read. The set is grown as the
+// words arrive, so a corrupted word count cannot cause a huge allocation.
+func (bs *BitSet) ReadFrom(r io.Reader) (int64, error) {
+	br := &byteCounter{r: r}
+	version, err := br.ReadByte()
+	if err != nil {
+		return br.n, fmt.Errorf("bitset: reading binary version: %w", noEOF(err))
+	}
+	if version != binaryVersion {
+		return br.n, fmt.Errorf("bitset: unsupported binary version %d", version)
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return br.n, fmt.Errorf("bitset: reading binary word count: %w", noEOF(err))
+	}
+	s := make(BitSet, 0, min(count, streamChunkWords))
+	buf := make([]byte, min(count, streamChunkWords)*8)
+	for uint64(len(s)) < count {
+		k := min(count-uint64(len(s)), streamChunkWords)
+		n, err := io.ReadFull(r, buf[:k*8])
+		br.n += int64(n)
+		if err != nil {
+			return br.n, fmt.Errorf("bitset: truncated stream after %d of %d words: %w",
+				len(s)+n/8, count, noEOF(err))
+		}
+		for j := 0; j < int(k); j++ {
+			s = append(s, binary.LittleEndian.Uint64(buf[j*8:]))
+		}
+	}
+	s.trim()
+	*bs = s
+	return br.n, nil
+}
+
+// byteCounter is an io.ByteReader over r that counts the bytes read.
+type byteCounter struct {
+	r   io.Reader
+	n   int64
+	buf [1]byte
+}
+
+func (b *byteCounter) ReadByte() (byte, error) {
+	n, err := io.ReadFull(b.r, b.buf[:])
+	b.n += int64(n)
+	return b.buf[0], err
+}
+
+// noEOF converts io.EOF into io.ErrUnexpectedEOF, since the end of input
+// is only expected after the last word.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Bytes returns the words of the set in little-endian order with trailing
+// zero bytes removed, so that bit j of byte i corresponds to element 8*i + j.
+// The layout is stable and can be decoded with FromBytes.
+func (bs BitSet) Bytes() []byte {
+	buf := make([]byte, 0, len(bs)*8)
+	for _, w := range bs {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	i := len(buf) - 1
+	for i >= 0 && buf[i] == 0 {
+		i--
+	}
+	return buf[:i+1]
+}
+
+// FromBytes creates a new set from the layout produced by Bytes,
+// where bit j of byte i corresponds to element 8*i + j.
+func FromBytes(b []byte) BitSet {
+	s := make(BitSet, (len(b)+7)/8)
+	for i := range s {
+		if len(b) >= 8 {
+			s[i] = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+			continue
+		}
+		var word [8]byte
+		copy(word[:], b)
+		s[i] = binary.LittleEndian.Uint64(word[:])
+	}
+	s.trim()
+	return s
+}
+
+// BitString returns the bits of the set as '0' and '1' characters, least
+// significant first, so that the character at index i corresponds to
+// element i. The string has Max()+1 characters, or none if the set is empty.
+// For example, {0 2} is "101".
+func (bs BitSet) BitString() string {
+	buf := make([]byte, bs.Max()+1)
+	for i := range buf {
+		buf[i] = '0'
+	}
+	bs.VisitAll(func(n int) {
+		buf[n] = '1'
+	})
+	return string(buf)
+}
+
+// FromBitString creates a new set from the format produced by BitString,
+// where the character at index i is '1' if i is in the set and '0' otherwise.
+func FromBitString(s string) (BitSet, error) {
+	bs := make(BitSet, (len(s)+bpw-1)>>shift)
+	for i := range len(s) {
+		switch s[i] {
+		case '0':
+		case '1':
+			bs[i>>shift] |= 1 << uint(i&div64rem)
+		default:
+			return nil, fmt.Errorf("bitset: invalid character %q at index %d", s[i], i)
+		}
+	}
+	bs.trim()
+	return bs, nil
+}
+
+// BitStringMSB returns the bits of the set as '0' and '1' characters in bytes
+// ordered most significant bit first: the string consists of groups of
+// eight characters, where group i covers the elements 8*i+7 down to 8*i.
+// For example, {0 2 9} is "0000010100000010".
+func (bs BitSet) BitStringMSB() string {
+	lsb := bs.BitString()
+	buf := make([]byte, (len(lsb)+7)&^7)
+	for i := range buf {
+		buf[i] = '0'
+	}
+	for i := range len(lsb) {
+		buf[i&^7+7-i&7] = lsb[i]
+	}
+	return string(buf)
+}
+
+// FromBitStringMSB creates a new set from the format produced by BitStringMSB.
+// The length of s must be a multiple of eight.
+func FromBitStringMSB(s string) (BitSet, error) {
+	if len(s)%8 != 0 {
+		return nil, fmt.Errorf("bitset: bit string length %d is not a multiple of 8", len(s))
+	}
+	bs := make(BitSet, (len(s)+bpw-1)>>shift)
+	for i := range len(s) {
+		n := i&^7 + 7 - i&7 // the element of the character at index i
+		switch s[i] {
+		case '0':
+		case '1':
+			bs[n>>shift] |= 1 << uint(n&div64rem)
+		default:
+			return nil, fmt.Errorf("bitset: invalid character %q at index %d", s[i], i)
+		}
+	}
+	bs.trim()
+	return bs, nil
+}
+
+// bigWordsPerWord is the number of big.Word values in a word of a set.
+const bigWordsPerWord = bpw / bits.UintSize
+
+// FromBigInt creates a new set with the elements n for which bit n of x
+// is set. A nil x is treated as zero. It returns an error if x is negative.
+func FromBigInt(x *big.Int) (BitSet, error) {
+	if x == nil {
+		return BitSet{}, nil
+	}
+	if x.Sign() < 0 {
+		return nil, fmt.Errorf("bitset: negative big.Int %v", x)
+	}
+	words := x.Bits() // little-endian and without leading zeros
+	s := make(BitSet, (len(words)+bigWordsPerWord-1)/bigWordsPerWord)
+	for i, w := range words {
+		s[i/bigWordsPerWord] |= uint64(w) << uint(bits.UintSize*(i%bigWordsPerWord))
+	}
+	return s, nil
+}
+
+// ToBigInt returns a non-negative big.Int with bit n set for every element n.
+func (bs BitSet) ToBigInt() *big.Int {
+	words := make([]big.Word, len(bs)*bigWordsPerWord)
+	for i, w := range bs {
+		for j := range bigWordsPerWord {
+			words[i*bigWordsPerWord+j] = big.Word(w >> uint(bits.UintSize*j))
+		}
+	}
+	return new(big.Int).SetBits(words)
+}
+
+// MarshalCompressed returns a compact encoding for sparse sets: the number of
+// elements followed by the first element and the gaps between consecutive
+// elements, all as unsigned varints. Bytes is smaller for dense sets.
+func (bs BitSet) MarshalCompressed() []byte {
+	size := bs.Size()
+	buf := make([]byte, 0, binary.MaxVarintLen64+size*2)
+	buf = binary.AppendUvarint(buf, uint64(size))
+	prev := 0
+	bs.VisitAll(func(n int) {
+		buf = binary.AppendUvarint(buf, uint64(n-prev))
+		prev = n
+	})
+	return buf
+}
+
+// compressedMinLimit is the greatest element UnmarshalCompressed accepts
+// regardless of the input length: its words take 8 MiB.
+const compressedMinLimit = 1<<26 - 1
+
+// compressedLimit returns the greatest element UnmarshalCompressed accepts
+// for n bytes of input, so that the words of the set take at most 1 KiB
+// per input byte, or 8 MiB for short inputs.
+func compressedLimit(n int) int {
+	if n > maxElement>>13 {
+		return maxElement
+	}
+	return max(compressedMinLimit, n<<13-1)
+}
+
+// UnmarshalCompressed creates a new set from the encoding produced by
+// MarshalCompressed. It validates the whole input before allocating the set
+// once, sized for its greatest element. A corrupted gap could otherwise
+// request terabytes, so the words of the set may take at most 8 MiB or
+// 1 KiB per input byte, whichever is more; use UnmarshalCompressedCapped
+// to choose the limit.
+func UnmarshalCompressed(data []byte) (BitSet, error) {
+	return UnmarshalCompressedCapped(data, compressedLimit(len(data)))
+}
+
+// UnmarshalCompressedCapped creates a new set from the encoding produced by
+// MarshalCompressed like UnmarshalCompressed, but returns an error if any
+// element exceeds maxAllowed or MaxElement.
+func UnmarshalCompressedCapped(data []byte, maxAllowed int) (BitSet, error) {
+	maxAllowed = min(maxAllowed, maxElement)
+	size, k := binary.Uvarint(data)
+	if k <= 0 {
+		return nil, errors.New("bitset: invalid compressed element count")
+	}
+	data = data[k:]
+	maxElem, rest := -1, data
+	for i := uint64(0); i < size; i++ {
+		gap, k := binary.Uvarint(rest)
+		if k <= 0 {
+			return nil, fmt.Errorf("bitset: truncated compressed data at element %d of %d", i, size)
+		}
+		rest = rest[k:]
+		if i > 0 && gap == 0 {
+			return nil, fmt.Errorf("bitset: repeated element %d in compressed data", maxElem)
+		}
+		prev := max(maxElem, 0)
+		if prev > maxAllowed || gap > uint64(maxAllowed-prev) {
+			return nil, fmt.Errorf("bitset: compressed element %d exceeds the maximum of %d", i, maxAllowed)
+		}
+		maxElem = prev + int(gap)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("bitset: %d trailing bytes after compressed data", len(rest))
+	}
+	if maxElem < 0 {
+		return BitSet{}, nil
+	}
+	s := make(BitSet, (maxElem>>shift)+1)
+	n := 0
+	for range size {
+		gap, k := binary.Uvarint(data)
+		data = data[k:]
+		n += int(gap)
+		s[n>>shift] |= 1 << uint(n&div64rem)
+	}
+	return s, nil
+}
+
+// HexString returns the layout produced by Bytes as lowercase hexadecimal,
+// so that byte i of the decoded string covers the elements 8*i to 8*i+7,
+// least significant bit first. For example, {0 9} is "0102".
+func (bs BitSet) HexString() string {
+	return hex.EncodeToString(bs.Bytes())
+}
+
+// FromHexString creates a new set from the format produced by HexString.
+// Both lowercase and uppercase digits are accepted.
+func FromHexString(s string) (BitSet, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("bitset: invalid hex string: %w", err)
+	}
+	return FromBytes(b), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The set is encoded as an array of its elements in ascending order, e.g. [1,2,3,65].
+func (bs BitSet) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 2+bs.Size()*4)
+	buf = append(buf, '[')
+	bs.VisitAll(func(n int) {
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendInt(buf, int64(n), 10)
+	})
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// It accepts an array of non-negative integers in any order, or null for
+// the empty set. Negative elements and elements greater than MaxElement
+// are rejected with an error.
+func (bs *BitSet) UnmarshalJSON(data []byte) error {
+	var elems []int
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return fmt.Errorf("bitset: %w", err)
+	}
+	for i, e := range elems {
+		if e < 0 {
+			return fmt.Errorf("bitset: negative element %d at index %d", e, i)
+		}
+	}
+	s, err := NewCapped(MaxElement, elems...)
+	if err != nil {
+		return err
+	}
+	*bs = s
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The set is encoded in the same format as produced by String.
+func (bs BitSet) MarshalText() ([]byte, error) {
+	return []byte(bs.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// It replaces the contents of *bs with the set parsed by ParseString.
+func (bs *BitSet) UnmarshalText(text []byte) error {
+	s, err := ParseString(string(text))
+	if err != nil {
+		return err
+	}
+	*bs = s
+	return nil
+}
+
+// ParseString parses a set in the format produced by String, e.g. "{0..3 5 7..9}".
+// The enclosing braces are optional, elements and ranges a..b are separated
+// by whitespace and may be given in any order. Elements greater than
+// MaxElement are rejected with an error.
+func ParseString(s string) (BitSet, error) {
+	body, offset := s, 0
+	switch {
+	case strings.HasPrefix(body, "{"):
+		if !strings.HasSuffix(body, "}") || len(body) == 1 {
+			return nil, fmt.Errorf("bitset: missing closing brace at position %d", len(s))
+		}
+		body, offset = body[1:len(body)-1], 1
+	case strings.HasSuffix(body, "}"):
+		return nil, fmt.Errorf("bitset: unexpected closing brace at position %d", len(s)-1)
+	}
+	bs := BitSet{}
+	for i := 0; i < len(body); {
+		if isSpace(body[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(body) && !isSpace(body[j]) {
+			j++
+		}
+		a, b, err := parseRange(body[i:j])
+		if err != nil {
+			return nil, fmt.Errorf("bitset: %w at position %d", err, offset+i)
+		}
+		if err := checkElement(b, maxElement); err != nil {
+			return nil, fmt.Errorf("%w at position %d", err, offset+i)
+		}
+		bs.AddRange(a, b+1)
+		i = j
+	}
+	return bs, nil
+}
+
+// FormatList returns the set in the list format of the Linux kernel used by
+// cpusets and sysfs files, e.g. "0-2,5,8-11". The empty set is "".
+func (bs BitSet) FormatList() string {
+	var buf []byte
+	for a, b := range bs.Ranges() {
+		if len(buf) > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendInt(buf, int64(a), 10)
+		if b > a {
+			buf = append(buf, '-')
+			buf = strconv.AppendInt(buf, int64(b), 10)
+		}
+	}
+	return string(buf)
+}
+
+// ParseList parses a set in the list format of the Linux kernel: comma
+// separated elements and ranges a-b, where a range may be followed by
+// :used/group to take only the first used elements of every group of
+// elements, e.g. "0-2,5,8-15:2/4" is {0..2 5 8 9 12 13}. An empty string
+// is the empty set, and a trailing newline, as read from sysfs, is ignored.
+// Elements greater than MaxElement are rejected with an error.
+func ParseList(s string) (BitSet, error) {
+	bs := BitSet{}
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return bs, nil
+	}
+	for pos := 0; pos <= len(s); {
+		tok, _, _ := strings.Cut(s[pos:], ",")
+		a, b, used, group, err := parseListRange(tok)
+		if err != nil {
+			return nil, fmt.Errorf("bitset: %w at position %d", err, pos)
+		}
+		if err := checkElement(b, maxElement); err != nil {
+			return nil, fmt.Errorf("%w at position %d", err, pos)
+		}
+		addListRange(&bs, a, b, used, group)
+		pos += len(tok) + 1
+	}
+	return bs, nil
+}
+
+// parseListRange parses a token of the list format, either "a", "a-b"
+// or "a-b:used/group", into the inclusive range [a, b] and its groups,
+// where used equals group if the token has none.
+func parseListRange(tok string) (a, b, used, group int, err error) {
+	rng, stride, hasStride := strings.Cut(tok, ":")
+	lo, hi, isRange := strings.Cut(rng, "-")
+	if a, err = parseElement(lo); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	b = a
+	if isRange {
+		if b, err = parseElement(hi); err != nil {
+			return 0, 0, 0, 0, err
+		}
+		if b < a {
+			return 0, 0, 0, 0, fmt.Errorf("invalid range %q", tok)
+		}
+	}
+	used, group = 1, 1
+	if hasStride {
+		if !isRange {
+			return 0, 0, 0, 0, fmt.Errorf("group without range %q", tok)
+		}
+		u, g, ok := strings.Cut(stride, "/")
+		if !ok {
+			return 0, 0, 0, 0, fmt.Errorf("invalid group %q", tok)
+		}
+		if used, err = parseElement(u); err != nil {
+			return 0, 0, 0, 0, err
+		}
+		if group, err = parseElement(g); err != nil {
+			return 0, 0, 0, 0, err
+		}
+		if used == 0 || group == 0 || used > group {
+			return 0, 0, 0, 0, fmt.Errorf("invalid group %q", tok)
+		}
+	}
+	return a, b, used, group, nil
+}
+
+// addListRange adds the first used elements of every group of elements
+// from a to b to bs, as parsed by parseListRange.
+func addListRange(bs *BitSet, a, b, used, group int) {
+	if used == group {
+		bs.AddRange(a, b+1)
+		return
+	}
+	for start := a; start <= b; start += group {
+		bs.AddRange(start, min(start+used, b+1))
+		if start > b-group { // the next group would overflow or start beyond b
+			break
+		}
+	}
+}
+
+// isSpace tells if c is an ASCII whitespace character.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// parseRange parses either "a" or "a..b" into the inclusive range [a, b].
+func parseRange(tok string) (a, b int, err error) {
+	lo, hi, isRange := strings.Cut(tok, "..")
+	if a, err = parseElement(lo); err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return a, a, nil
+	}
+	if b, err = parseElement(hi); err != nil {
+		return 0, 0, err
+	}
+	if b < a {
+		return 0, 0, fmt.Errorf("invalid range %q", tok)
+	}
+	return a, b, nil
+}
+
+// parseElement parses a non-negative decimal integer less than math.MaxInt.
+func parseElement(tok string) (int, error) {
+	if tok == "" {
+		return 0, errors.New("missing element")
+	}
+	for i := 0; i < len(tok); i++ {
+		if tok[i] < '0' || tok[i] > '9' {
+			return 0, fmt.Errorf("invalid element %q", tok)
+		}
+	}
+	n, err := strconv.Atoi(tok)
+	if err != nil || n == math.MaxInt {
+		return 0, fmt.Errorf("element %q out of range", tok)
+	}
+	return n, nil
+}
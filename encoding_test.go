@@ -0,0 +1,856 @@
+package bitset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitSet_MarshalBinary(t *testing.T) {
+	large := New()
+	for i := 0; i < 5000*64; i += 3 {
+		large.Add(i)
+	}
+
+	tests := []struct {
+		name string
+		bs   BitSet
+	}{
+		{"empty", New()},
+		{"single word", New(0, 1, 63)},
+		{"boundary", New(63, 64, 65)},
+		{"multi kiloword", large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.bs.MarshalBinary()
+			require.NoError(t, err)
+
+			var got BitSet
+			require.NoError(t, got.UnmarshalBinary(data))
+			require.True(t, got.Equal(tt.bs))
+		})
+	}
+
+	t.Run("layout", func(t *testing.T) {
+		data, err := New(0, 65).MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, []byte{
+			1, 2,
+			1, 0, 0, 0, 0, 0, 0, 0,
+			2, 0, 0, 0, 0, 0, 0, 0,
+		}, data)
+	})
+}
+
+func TestBitSet_UnmarshalBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		expect string
+		err    string
+	}{
+		{"empty input", nil, "", "bitset: missing binary version"},
+		{"unknown version", []byte{2, 0}, "", "bitset: unsupported binary version 2"},
+		{"missing count", []byte{1}, "", "bitset: invalid binary word count"},
+		{"truncated word", []byte{1, 1, 1, 0, 0}, "", "bitset: binary data holds 3 bytes, expected 1 words"},
+		{"missing word", []byte{1, 2, 1, 0, 0, 0, 0, 0, 0, 0}, "", "bitset: binary data holds 8 bytes, expected 2 words"},
+		{"extra bytes", []byte{1, 0, 1}, "", "bitset: binary data holds 1 bytes, expected 0 words"},
+		{"empty set", []byte{1, 0}, "{}", ""},
+		{"trailing zero words", []byte{
+			1, 2,
+			2, 0, 0, 0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0, 0, 0, 0,
+		}, "{1}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(1000)
+			err := bs.UnmarshalBinary(tt.data)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Equal(t, "{1000}", bs.String())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, bs.String())
+			require.True(t, bs.Equal(New(bs.ToSlice()...)))
+		})
+	}
+}
+
+func FuzzBitSet_UnmarshalBinary(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 0})
+	f.Add([]byte{1, 1, 1, 0, 0, 0, 0, 0, 0, 0})
+	f.Add([]byte{1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var bs BitSet
+		if err := bs.UnmarshalBinary(data); err != nil {
+			return
+		}
+		require.True(t, bs.Empty() || bs[len(bs)-1] != 0)
+	})
+}
+
+func TestBitSet_Gob(t *testing.T) {
+	type record struct {
+		Name    string
+		Members BitSet
+		Nil     BitSet
+		Ptr     *BitSet
+	}
+	members := New(1, 64, 1000)
+	in := record{Name: "a", Members: New(0, 2, 300), Ptr: &members}
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(in))
+	var out record
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&out))
+	require.Equal(t, "a", out.Name)
+	require.Equal(t, "{0 2 300}", out.Members.String())
+	require.Equal(t, "{1 64 1000}", out.Ptr.String())
+	require.True(t, out.Nil.Empty())
+
+	t.Run("payload", func(t *testing.T) {
+		data, err := New(3).GobEncode()
+		require.NoError(t, err)
+		expect, err := New(3).MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, expect, data)
+
+		bs := New(1)
+		require.NoError(t, bs.GobDecode(nil))
+		require.True(t, bs.Empty())
+		require.EqualError(t, bs.GobDecode([]byte{9, 0}), "bitset: unsupported binary version 9")
+	})
+}
+
+func TestBitSet_WriteTo(t *testing.T) {
+	large := New()
+	for i := 0; i < 3000*64; i += 5 {
+		large.Add(i)
+	}
+
+	tests := []struct {
+		name string
+		bs   BitSet
+	}{
+		{"empty", New()},
+		{"single word", New(0, 1, 63)},
+		{"boundary", New(63, 64, 65)},
+		{"multi chunk", large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := tt.bs.WriteTo(&buf)
+			require.NoError(t, err)
+			require.Equal(t, int64(buf.Len()), n)
+
+			data, err := tt.bs.MarshalBinary()
+			require.NoError(t, err)
+			require.Equal(t, data, buf.Bytes())
+
+			got := New(1000)
+			n, err = got.ReadFrom(&buf)
+			require.NoError(t, err)
+			require.Equal(t, int64(len(data)), n)
+			require.True(t, got.Equal(tt.bs))
+		})
+	}
+
+	t.Run("pipe", func(t *testing.T) {
+		bs := New()
+		bs.AddRange(0, 5_000_000)
+		for i := 5_000_000; i < 15_000_000; i += 2 {
+			bs.Add(i)
+		}
+		require.Equal(t, 10_000_000, bs.Size())
+
+		pr, pw := io.Pipe()
+		go func() {
+			_, err := bs.WriteTo(pw)
+			pw.CloseWithError(err)
+		}()
+		var got BitSet
+		_, err := got.ReadFrom(pr)
+		require.NoError(t, err)
+		require.True(t, got.Equal(bs))
+	})
+}
+
+func TestBitSet_ReadFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		n      int64
+		expect string
+		err    string
+	}{
+		{"empty input", nil, 0, "", "bitset: reading binary version: unexpected EOF"},
+		{"unknown version", []byte{2, 0}, 1, "", "bitset: unsupported binary version 2"},
+		{"missing count", []byte{1}, 1, "", "bitset: reading binary word count: unexpected EOF"},
+		{"truncated word", []byte{1, 1, 1, 0, 0}, 5, "", "bitset: truncated stream after 0 of 1 words: unexpected EOF"},
+		{"missing word", []byte{1, 2, 1, 0, 0, 0, 0, 0, 0, 0}, 10, "", "bitset: truncated stream after 1 of 2 words: unexpected EOF"},
+		{"huge count", []byte{1, 0xff, 0xff, 0xff, 0xff, 0x0f}, 6, "", "bitset: truncated stream after 0 of 4294967295 words: unexpected EOF"},
+		{"empty set", []byte{1, 0}, 2, "{}", ""},
+		{"trailing zero words", []byte{
+			1, 2,
+			2, 0, 0, 0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0, 0, 0, 0,
+		}, 18, "{1}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(1000)
+			n, err := bs.ReadFrom(bytes.NewReader(tt.data))
+			require.Equal(t, tt.n, n)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Equal(t, "{1000}", bs.String())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, bs.String())
+		})
+	}
+}
+
+func TestBitSet_Bytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect string
+	}{
+		{"empty", New(), ""},
+		{"zero", New(0), "01"},
+		{"first byte", New(0, 2, 7), "85"},
+		{"second byte", New(8), "0001"},
+		{"word boundary", New(63, 64), "000000000000008001"},
+		{"partial last word", New(1, 65, 80), "0200000000000000020001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.bs.Bytes()
+			require.Equal(t, tt.expect, hex.EncodeToString(got))
+
+			decoded := FromBytes(got)
+			require.True(t, decoded.Equal(tt.bs))
+		})
+	}
+
+	t.Run("trailing zero bytes", func(t *testing.T) {
+		got := FromBytes([]byte{0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+		require.Equal(t, "{1}", got.String())
+		require.Len(t, got, 1)
+		require.True(t, FromBytes([]byte{0, 0}).Empty())
+		require.True(t, FromBytes(nil).Empty())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		bs := New()
+		for i := 0; i < 5000; i += 7 {
+			bs.Add(i)
+		}
+		require.True(t, FromBytes(bs.Bytes()).Equal(bs))
+	})
+}
+
+func TestList(t *testing.T) {
+	// Contents of /sys/devices/system/cpu files and cpuset.cpus as written
+	// by the kernel, including the trailing newline.
+	tests := []struct {
+		name   string
+		sysfs  string
+		expect string
+	}{
+		{"offline none", "\n", "{}"},
+		{"online single", "0\n", "{0}"},
+		{"online pair", "0-1\n", "{0 1}"},
+		{"online", "0-7\n", "{0..7}"},
+		{"isolated", "2-3,6-7\n", "{2 3 6 7}"},
+		{"numa node", "0-23,48-71\n", "{0..23 48..71}"},
+		{"mixed", "0,2,4-5,64-127\n", "{0 2 4 5 64..127}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, err := ParseList(tt.sysfs)
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, bs.String())
+			require.Equal(t, tt.sysfs, bs.FormatList()+"\n")
+		})
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		for s, expect := range map[string]string{
+			"":               "{}",
+			"5":              "{5}",
+			"3-3":            "{3}",
+			"0-3,1-2":        "{0..3}",
+			"8-15:2/4":       "{8 9 12 13}",
+			"0-9:1/3":        "{0 3 6 9}",
+			"0-10:3/3":       "{0..10}",
+			"0-1023:2/256":   "{0 1 256 257 512 513 768 769}",
+			"0-2,5,8-15:2/4": "{0..2 5 8 9 12 13}",
+		} {
+			bs, err := ParseList(s)
+			require.NoError(t, err, s)
+			require.Equal(t, expect, bs.String(), s)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for s, expect := range map[string]string{
+			",":       `bitset: missing element at position 0`,
+			"0,":      `bitset: missing element at position 2`,
+			"0,,1":    `bitset: missing element at position 2`,
+			"1-":      `bitset: missing element at position 0`,
+			"3-1":     `bitset: invalid range "3-1" at position 0`,
+			"0-3,x":   `bitset: invalid element "x" at position 4`,
+			"-1":      `bitset: missing element at position 0`,
+			" 1":      `bitset: invalid element " 1" at position 0`,
+			"1\n\n":   `bitset: invalid element "1\n" at position 0`,
+			"5:1/2":   `bitset: group without range "5:1/2" at position 0`,
+			"0-7:2":   `bitset: invalid group "0-7:2" at position 0`,
+			"0-7:3/2": `bitset: invalid group "0-7:3/2" at position 0`,
+			"0-7:0/2": `bitset: invalid group "0-7:0/2" at position 0`,
+			"0-7:1/x": `bitset: invalid element "x" at position 0`,
+		} {
+			_, err := ParseList(s)
+			require.EqualError(t, err, expect, s)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		maxElement = 1000
+		t.Cleanup(func() { maxElement = MaxElement })
+		for s, expect := range map[string]string{
+			"1,1001":           "bitset: element 1001 exceeds the maximum of 1000 at position 2",
+			"0-5,990-2000:1/4": "bitset: element 2000 exceeds the maximum of 1000 at position 4",
+		} {
+			_, err := ParseList(s)
+			require.EqualError(t, err, expect, s)
+		}
+		bs, err := ParseList("999-1000,0-1000:1/500")
+		require.NoError(t, err)
+		require.Equal(t, "{0 500 999 1000}", bs.String())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(21, 22))
+		for range 100 {
+			bs := New()
+			for range r.IntN(20) {
+				a := r.IntN(500)
+				bs.AddRange(a, a+r.IntN(10))
+			}
+			got, err := ParseList(bs.FormatList())
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs))
+		}
+	})
+}
+
+func TestBitSet_BitString(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+		lsb  string
+		msb  string
+	}{
+		{"empty", New(), "", ""},
+		{"zero", New(0), "1", "00000001"},
+		{"fixed vector", New(0, 2), "101", "00000101"},
+		{"second byte", New(0, 2, 9), "1010000001", "0000010100000010"},
+		{"full byte", FromRange(0, 8), "11111111", "11111111"},
+		{"word boundary", New(63, 64), strings.Repeat("0", 63) + "11", strings.Repeat("0", 56) + "10000000" + "00000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.lsb, tt.bs.BitString())
+			require.Equal(t, tt.msb, tt.bs.BitStringMSB())
+
+			bs, err := FromBitString(tt.lsb)
+			require.NoError(t, err)
+			require.True(t, bs.Equal(tt.bs))
+			bs, err = FromBitStringMSB(tt.msb)
+			require.NoError(t, err)
+			require.True(t, bs.Equal(tt.bs))
+		})
+	}
+
+	t.Run("trailing zeros", func(t *testing.T) {
+		bs, err := FromBitString("0100" + strings.Repeat("0", 200))
+		require.NoError(t, err)
+		require.Equal(t, "{1}", bs.String())
+		require.NoError(t, bs.Validate())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := FromBitString("0110x1")
+		require.EqualError(t, err, `bitset: invalid character 'x' at index 4`)
+		_, err = FromBitStringMSB("0000000 ")
+		require.EqualError(t, err, `bitset: invalid character ' ' at index 7`)
+		_, err = FromBitStringMSB("101")
+		require.EqualError(t, err, "bitset: bit string length 3 is not a multiple of 8")
+	})
+
+	t.Run("random", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(15, 16))
+		for range 100 {
+			bs := New()
+			for range r.IntN(30) {
+				bs.Add(r.IntN(500))
+			}
+			got, err := FromBitString(bs.BitString())
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs))
+			got, err = FromBitStringMSB(bs.BitStringMSB())
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs))
+		}
+	})
+}
+
+func TestBitSet_MarshalCompressed(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+		data []byte
+	}{
+		{"empty", New(), []byte{0}},
+		{"zero", New(0), []byte{1, 0}},
+		{"gaps", New(1, 2, 300), []byte{3, 1, 1, 0xaa, 0x02}},
+		{"sparse", New(10_000_000), []byte{1, 0x80, 0xad, 0xe2, 0x04}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.bs.MarshalCompressed()
+			require.Equal(t, tt.data, data)
+			bs, err := UnmarshalCompressed(data)
+			require.NoError(t, err)
+			require.True(t, bs.Equal(tt.bs))
+			require.NoError(t, bs.Validate())
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(19, 20))
+		for range 100 {
+			bs := New()
+			for range r.IntN(100) {
+				bs.Add(r.IntN(100_000))
+			}
+			got, err := UnmarshalCompressed(bs.MarshalCompressed())
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs))
+		}
+	})
+}
+
+func TestUnmarshalCompressed_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", nil, "bitset: invalid compressed element count"},
+		{"truncated count", []byte{0x80}, "bitset: invalid compressed element count"},
+		{"missing elements", []byte{3, 1, 1}, "bitset: truncated compressed data at element 2 of 3"},
+		{"truncated varint", []byte{2, 1, 0x80}, "bitset: truncated compressed data at element 1 of 2"},
+		{"repeated element", []byte{2, 5, 0}, "bitset: repeated element 5 in compressed data"},
+		{"trailing bytes", []byte{1, 5, 7, 7}, "bitset: 2 trailing bytes after compressed data"},
+		{"huge count", []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 1}, "bitset: truncated compressed data at element 1 of 4294967295"},
+		{
+			"overflowing gap", []byte{1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+			fmt.Sprintf("bitset: compressed element 0 exceeds the maximum of %d", compressedMinLimit),
+		},
+		{
+			"overflowing sum", binary.AppendUvarint([]byte{2, 1}, uint64(MaxElement)),
+			fmt.Sprintf("bitset: compressed element 1 exceeds the maximum of %d", compressedMinLimit),
+		},
+		{
+			"implausible gap", binary.AppendUvarint([]byte{1}, uint64(MaxElement-1)),
+			fmt.Sprintf("bitset: compressed element 0 exceeds the maximum of %d", compressedMinLimit),
+		},
+		{
+			"implausible sum", binary.AppendUvarint([]byte{2, 1}, compressedMinLimit),
+			fmt.Sprintf("bitset: compressed element 1 exceeds the maximum of %d", compressedMinLimit),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, err := UnmarshalCompressed(tt.data)
+			require.EqualError(t, err, tt.err)
+			require.Nil(t, bs)
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		bs := New(compressedMinLimit)
+		got, err := UnmarshalCompressed(bs.MarshalCompressed())
+		require.NoError(t, err)
+		require.True(t, got.Equal(bs))
+
+		// Longer inputs may encode greater elements.
+		require.Equal(t, compressedMinLimit, compressedLimit(0))
+		require.Equal(t, 1<<30-1, compressedLimit(1<<17))
+		require.Equal(t, MaxElement, compressedLimit(math.MaxInt))
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		data := New(5, 1000).MarshalCompressed()
+		bs, err := UnmarshalCompressedCapped(data, 1000)
+		require.NoError(t, err)
+		require.Equal(t, "{5 1000}", bs.String())
+		_, err = UnmarshalCompressedCapped(data, 999)
+		require.EqualError(t, err, "bitset: compressed element 1 exceeds the maximum of 999")
+		_, err = UnmarshalCompressedCapped(data, -1)
+		require.EqualError(t, err, "bitset: compressed element 0 exceeds the maximum of -1")
+		bs, err = UnmarshalCompressedCapped(New(compressedMinLimit+1).MarshalCompressed(), math.MaxInt)
+		require.NoError(t, err)
+		require.Equal(t, compressedMinLimit+1, bs.Max())
+	})
+}
+
+func FuzzUnmarshalCompressed(f *testing.F) {
+	f.Add([]byte{0})
+	f.Add([]byte{3, 1, 1, 0xaa, 0x02})
+	f.Add([]byte{1, 0x80, 0xad, 0xe2, 0x04})
+	f.Add(binary.AppendUvarint([]byte{1}, uint64(MaxElement-1)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bs, err := UnmarshalCompressed(data)
+		if err != nil {
+			return
+		}
+		require.NoError(t, bs.Validate())
+		// The input may use longer varints than MarshalCompressed.
+		again, err := UnmarshalCompressed(bs.MarshalCompressed())
+		require.NoError(t, err)
+		require.True(t, again.Equal(bs))
+	})
+}
+
+func TestBitSet_HexString(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+		hex  string
+	}{
+		{"empty", New(), ""},
+		{"zero", New(0), "01"},
+		{"second byte", New(0, 9), "0102"},
+		{"high bits", New(7, 15), "8080"},
+		{"nibbles", New(0, 1, 2, 3, 12), "0f10"},
+		{"word boundary", New(63, 64), "000000000000008001"},
+		{"sparse", New(100), "00000000000000000000000010"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.hex, tt.bs.HexString())
+			bs, err := FromHexString(tt.hex)
+			require.NoError(t, err)
+			require.True(t, bs.Equal(tt.bs))
+			bs, err = FromHexString(strings.ToUpper(tt.hex))
+			require.NoError(t, err)
+			require.True(t, bs.Equal(tt.bs))
+		})
+	}
+
+	t.Run("trailing zeros", func(t *testing.T) {
+		bs, err := FromHexString("0100000000000000000000")
+		require.NoError(t, err)
+		require.Equal(t, "{0}", bs.String())
+		require.NoError(t, bs.Validate())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := FromHexString("010")
+		require.ErrorIs(t, err, hex.ErrLength)
+		require.EqualError(t, err, "bitset: invalid hex string: encoding/hex: odd length hex string")
+		_, err = FromHexString("01zz")
+		require.EqualError(t, err, "bitset: invalid hex string: encoding/hex: invalid byte: U+007A 'z'")
+	})
+
+	t.Run("random", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(17, 18))
+		for range 100 {
+			bs := New()
+			for range r.IntN(30) {
+				bs.Add(r.IntN(1000))
+			}
+			got, err := FromHexString(bs.HexString())
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs))
+		}
+	})
+}
+
+func TestBigInt(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+		x    string
+	}{
+		{"empty", New(), "0"},
+		{"small", New(0, 2), "5"},
+		{"word boundary", New(63, 64), "0x18000000000000000"},
+		{"multi word", New(1, 128), "0x100000000000000000000000000000002"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, ok := new(big.Int).SetString(tt.x, 0)
+			require.True(t, ok)
+			require.Zero(t, x.Cmp(tt.bs.ToBigInt()), tt.bs.ToBigInt().Text(16))
+			bs, err := FromBigInt(x)
+			require.NoError(t, err)
+			require.True(t, bs.Equal(tt.bs))
+			require.NoError(t, bs.Validate())
+		})
+	}
+
+	t.Run("negative", func(t *testing.T) {
+		_, err := FromBigInt(big.NewInt(-5))
+		require.EqualError(t, err, "bitset: negative big.Int -5")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		bs, err := FromBigInt(nil)
+		require.NoError(t, err)
+		require.Equal(t, BitSet{}, bs)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		r := rand.New(rand.NewPCG(13, 14))
+		for range 100 {
+			bs := New()
+			ref := new(big.Int)
+			for range r.IntN(50) {
+				n := r.IntN(1000)
+				bs.Add(n)
+				ref.SetBit(ref, n, 1)
+			}
+			require.Zero(t, ref.Cmp(bs.ToBigInt()))
+			got, err := FromBigInt(ref)
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs))
+			for n := range 1000 {
+				require.Equal(t, ref.Bit(n) == 1, got.Contains(n))
+			}
+		}
+	})
+}
+
+func TestBitSet_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect string
+	}{
+		{"nil", nil, "[]"},
+		{"empty", New(), "[]"},
+		{"single", New(1), "[1]"},
+		{"several", New(1, 2, 3, 65), "[1,2,3,65]"},
+		{"boundary", New(0, 63, 64), "[0,63,64]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.bs)
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, string(data))
+
+			var got BitSet
+			require.NoError(t, json.Unmarshal(data, &got))
+			require.True(t, got.Equal(tt.bs))
+		})
+	}
+
+	t.Run("struct field", func(t *testing.T) {
+		type item struct {
+			Name string `json:"name"`
+			Tags BitSet `json:"tags"`
+		}
+		data, err := json.Marshal(item{Name: "a", Tags: New(3, 100)})
+		require.NoError(t, err)
+		require.Equal(t, `{"name":"a","tags":[3,100]}`, string(data))
+
+		var got item
+		require.NoError(t, json.Unmarshal(data, &got))
+		require.Equal(t, "a", got.Name)
+		require.Equal(t, "{3 100}", got.Tags.String())
+	})
+}
+
+func TestBitSet_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		expect string
+		err    string
+	}{
+		{"null", "null", "{}", ""},
+		{"empty", "[]", "{}", ""},
+		{"unsorted duplicates", "[65,3,3,1]", "{1 3 65}", ""},
+		{"negative", "[1,-2]", "", "bitset: negative element -2 at index 1"},
+		{"not an array", `{"a":1}`, "", "cannot unmarshal object"},
+		{"float", "[1.5]", "", "cannot unmarshal number 1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(1000)
+			err := bs.UnmarshalJSON([]byte(tt.data))
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, bs.String())
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		var bs BitSet
+		require.Error(t, json.Unmarshal([]byte("[4000000000000000]"), &bs))
+
+		maxElement = 1000
+		t.Cleanup(func() { maxElement = MaxElement })
+		bs = New(7)
+		require.EqualError(t, bs.UnmarshalJSON([]byte("[1,1001]")),
+			"bitset: element 1001 exceeds the maximum of 1000")
+		require.Equal(t, "{7}", bs.String())
+		require.NoError(t, bs.UnmarshalJSON([]byte("[1,1000]")))
+		require.Equal(t, "{1 1000}", bs.String())
+	})
+}
+
+func TestBitSet_MarshalText(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+	}{
+		{"empty", New()},
+		{"single", New(1)},
+		{"pair", New(1, 2)},
+		{"ranges", New(0, 1, 2, 3, 5, 7, 8, 9)},
+		{"boundary", New(62, 63, 64, 65, 200)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := tt.bs.MarshalText()
+			require.NoError(t, err)
+			require.Equal(t, tt.bs.String(), string(text))
+
+			var got BitSet
+			require.NoError(t, got.UnmarshalText(text))
+			require.True(t, got.Equal(tt.bs))
+		})
+	}
+
+	t.Run("flag", func(t *testing.T) {
+		var bs BitSet
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.TextVar(&bs, "set", New(), "set of elements")
+		require.NoError(t, fs.Parse([]string{"-set", "1..3 7"}))
+		require.Equal(t, "{1..3 7}", bs.String())
+	})
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		expect string
+		err    string
+	}{
+		{"empty string", "", "{}", ""},
+		{"empty braces", "{}", "{}", ""},
+		{"single", "{1}", "{1}", ""},
+		{"ranges", "{1..3 7 100..200}", "{1..3 7 100..200}", ""},
+		{"no braces", "1..3 7", "{1..3 7}", ""},
+		{"extra whitespace", "{ 1  2\t3\n}", "{1..3}", ""},
+		{"unordered overlapping", "{7 1..3 2..5}", "{1..5 7}", ""},
+		{"single elem range", "{4..4}", "{4}", ""},
+		{"missing closing brace", "{1 2", "", "bitset: missing closing brace at position 4"},
+		{"lone opening brace", "{", "", "bitset: missing closing brace at position 1"},
+		{"unexpected closing brace", "1 2}", "", "bitset: unexpected closing brace at position 3"},
+		{"reversed range", "{1 5..3}", "", `bitset: invalid range "5..3" at position 3`},
+		{"negative", "{1 -2}", "", `bitset: invalid element "-2" at position 3`},
+		{"garbage", "{1 a}", "", `bitset: invalid element "a" at position 3`},
+		{"open range", "{1..}", "", "bitset: missing element at position 1"},
+		{"triple dots", "{1...3}", "", `bitset: invalid element ".3" at position 1`},
+		{"overflow", "{99999999999999999999}", "", `bitset: element "99999999999999999999" out of range at position 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseString(tt.s)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, got.String())
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		maxElement = 1000
+		t.Cleanup(func() { maxElement = MaxElement })
+
+		bs, err := ParseString("{5 990..1000}")
+		require.NoError(t, err)
+		require.Equal(t, "{5 990..1000}", bs.String())
+		_, err = ParseString("{5 990..1001}")
+		require.EqualError(t, err, "bitset: element 1001 exceeds the maximum of 1000 at position 3")
+		require.EqualError(t, bs.UnmarshalText([]byte("1001")),
+			"bitset: element 1001 exceeds the maximum of 1000 at position 0")
+		require.Equal(t, "{5 990..1000}", bs.String())
+	})
+}
+
+func FuzzParseString(f *testing.F) {
+	f.Add("{1..3 7 100..200}")
+	f.Add("{ 1  2\t3\n}")
+	f.Add("{4000000000000000}")
+	f.Add("1..99999999999999999999")
+	// Elements up to MaxElement are valid but would exhaust the memory.
+	maxElement = 1 << 20
+	f.Cleanup(func() { maxElement = MaxElement })
+	f.Fuzz(func(t *testing.T, s string) {
+		bs, err := ParseString(s)
+		if err != nil {
+			return
+		}
+		require.NoError(t, bs.Validate())
+		again, err := ParseString(bs.String())
+		require.NoError(t, err)
+		require.True(t, again.Equal(bs))
+	})
+}
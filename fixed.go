@@ -0,0 +1,110 @@
+package bitset
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Fixed256 is a set of integers from 0 to 255 stored inline in four words,
+// so it requires no heap allocation. The zero value of Fixed256 is an empty set.
+// Elements outside of [0, 256) are ignored by all methods.
+type Fixed256 [4]uint64
+
+// fixed256Max is the maximum element of Fixed256.
+const fixed256Max = len(Fixed256{})<<shift - 1
+
+// Add adds the given elements to f.
+func (f *Fixed256) Add(n ...int) {
+	for _, e := range n {
+		if e >= 0 && e <= fixed256Max {
+			f[e>>shift] |= 1 << uint(e&div64rem)
+		}
+	}
+}
+
+// Delete removes the given elements from f.
+func (f *Fixed256) Delete(n ...int) {
+	for _, e := range n {
+		if e >= 0 && e <= fixed256Max {
+			f[e>>shift] &^= 1 << uint(e&div64rem)
+		}
+	}
+}
+
+// Contains tells if n is in the set.
+func (f Fixed256) Contains(n int) bool {
+	if n < 0 || n > fixed256Max {
+		return false
+	}
+	return f[n>>shift]&(1<<uint(n&div64rem)) != 0
+}
+
+// Size returns the number of elements in the set.
+func (f Fixed256) Size() int {
+	return bits.OnesCount64(f[0]) + bits.OnesCount64(f[1]) +
+		bits.OnesCount64(f[2]) + bits.OnesCount64(f[3])
+}
+
+// Empty tells if the set is empty.
+func (f Fixed256) Empty() bool {
+	return f == Fixed256{}
+}
+
+// Visit calls the do function for each element of f in numerical order
+// with the same abort semantics as BitSet.Visit.
+func (f Fixed256) Visit(do func(n int) bool) (aborted bool) {
+	for i, w := range f {
+		for w != 0 {
+			if do((i << shift) + bits.TrailingZeros64(w)) {
+				return true
+			}
+			w &= w - 1 // clear the lowest set bit
+		}
+	}
+	return false
+}
+
+// And returns the set of elements in both f and other.
+func (f Fixed256) And(other Fixed256) Fixed256 {
+	return Fixed256{f[0] & other[0], f[1] & other[1], f[2] & other[2], f[3] & other[3]}
+}
+
+// Or returns the set of elements in f or other.
+func (f Fixed256) Or(other Fixed256) Fixed256 {
+	return Fixed256{f[0] | other[0], f[1] | other[1], f[2] | other[2], f[3] | other[3]}
+}
+
+// Xor returns the set of elements in f or other but not both.
+func (f Fixed256) Xor(other Fixed256) Fixed256 {
+	return Fixed256{f[0] ^ other[0], f[1] ^ other[1], f[2] ^ other[2], f[3] ^ other[3]}
+}
+
+// AndNot returns the set of elements in f but not in other.
+func (f Fixed256) AndNot(other Fixed256) Fixed256 {
+	return Fixed256{f[0] &^ other[0], f[1] &^ other[1], f[2] &^ other[2], f[3] &^ other[3]}
+}
+
+// ToBitSet creates a new BitSet with the elements of f.
+func (f Fixed256) ToBitSet() BitSet {
+	s := make(BitSet, len(f))
+	copy(s, f[:])
+	s.trim()
+	return s
+}
+
+// String returns a string representation of the set in the same format
+// as BitSet.String.
+func (f Fixed256) String() string {
+	return f.ToBitSet().String()
+}
+
+// FromBitSet creates a new Fixed256 with the elements of bs.
+// It returns an error if bs has elements greater than 255.
+func FromBitSet(bs BitSet) (Fixed256, error) {
+	var f Fixed256
+	if m := bs.Max(); m > fixed256Max {
+		return f, fmt.Errorf("bitset: element %d exceeds the maximum of %d", m, fixed256Max)
+	}
+	copy(f[:], bs)
+	return f, nil
+}
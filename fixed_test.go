@@ -0,0 +1,100 @@
+package bitset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixed256(t *testing.T) {
+	var f Fixed256
+	require.True(t, f.Empty())
+	f.Add(0, 63, 64, 255, -1, 256)
+	require.Equal(t, "{0 63 64 255}", f.String())
+	require.Equal(t, 4, f.Size())
+	require.True(t, f.Contains(255))
+	require.False(t, f.Contains(256))
+	require.False(t, f.Contains(-1))
+	require.False(t, f.Contains(1))
+
+	f.Delete(63, 256, -1)
+	require.Equal(t, "{0 64 255}", f.String())
+	require.False(t, f.Empty())
+
+	var visited []int
+	f.Visit(func(n int) bool {
+		visited = append(visited, n)
+		return n == 64
+	})
+	require.Equal(t, []int{0, 64}, visited)
+}
+
+func TestFixed256_Ops(t *testing.T) {
+	var a, b Fixed256
+	a.Add(1, 2, 100, 200)
+	b.Add(2, 3, 200, 250)
+
+	tests := []struct {
+		name   string
+		got    Fixed256
+		expect string
+	}{
+		{"and", a.And(b), "{2 200}"},
+		{"or", a.Or(b), "{1..3 100 200 250}"},
+		{"xor", a.Xor(b), "{1 3 100 250}"},
+		{"and not", a.AndNot(b), "{1 100}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, tt.got.String())
+		})
+	}
+}
+
+func TestFixed256_BitSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		bs     BitSet
+		expect string
+		err    string
+	}{
+		{"empty", New(), "{}", ""},
+		{"small", New(1, 64), "{1 64}", ""},
+		{"max", New(0, 255), "{0 255}", ""},
+		{"too large", New(1, 256), "", "bitset: element 256 exceeds the maximum of 255"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := FromBitSet(tt.bs)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, f.String())
+			require.True(t, f.ToBitSet().Equal(tt.bs))
+		})
+	}
+}
+
+func TestFixed256_NoAllocation(t *testing.T) {
+	sum := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		var a, b Fixed256
+		a.Add(1, 64, 200)
+		b.Add(64, 255)
+		a.Delete(1)
+		c := a.Or(b).And(a.Xor(b)).AndNot(b)
+		sum += c.Size()
+		if c.Contains(64) {
+			sum++
+		}
+		c.Visit(func(n int) bool {
+			sum += n
+			return false
+		})
+	})
+	require.Zero(t, allocs)
+}
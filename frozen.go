@@ -0,0 +1,119 @@
+package bitset
+
+// Frozen is an immutable set that is safe to share between goroutines
+// without copying. Its size, minimum, maximum and hash are computed once
+// when it is created. The zero value of Frozen is an empty set.
+type Frozen struct {
+	bs       BitSet
+	size     int
+	min, max int
+	hash     uint64
+	nonEmpty bool
+}
+
+// FNV-1a parameters used by Frozen.Hash.
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// Freeze returns an immutable copy of bs with trimmed and clipped storage.
+// Later changes to bs don't affect the returned set.
+func (bs BitSet) Freeze() Frozen {
+	l := bs.trimmedLen()
+	if l == 0 {
+		return Frozen{}
+	}
+	words := make(BitSet, l)
+	copy(words, bs)
+	hash := uint64(fnvOffset64)
+	for _, w := range words {
+		for range 8 {
+			hash ^= w & 0xff
+			hash *= fnvPrime64
+			w >>= 8
+		}
+	}
+	return Frozen{
+		bs:       words,
+		size:     words.Size(),
+		min:      words.Min(),
+		max:      words.Max(),
+		hash:     hash,
+		nonEmpty: true,
+	}
+}
+
+// Contains tells if n is in the set.
+func (f Frozen) Contains(n int) bool {
+	return f.bs.Contains(n)
+}
+
+// Size returns the number of elements in the set.
+func (f Frozen) Size() int {
+	return f.size
+}
+
+// Empty tells if the set is empty.
+func (f Frozen) Empty() bool {
+	return !f.nonEmpty
+}
+
+// Min returns the minimum element of the set.
+// If the set is empty, -1 is returned.
+func (f Frozen) Min() int {
+	if !f.nonEmpty {
+		return -1
+	}
+	return f.min
+}
+
+// Max returns the maximum element of the set.
+// If the set is empty, -1 is returned.
+func (f Frozen) Max() int {
+	if !f.nonEmpty {
+		return -1
+	}
+	return f.max
+}
+
+// Hash returns the 64-bit FNV-1a hash of the little-endian words of the set.
+// Equal sets have equal hashes; the empty set hashes to 0.
+func (f Frozen) Hash() uint64 {
+	return f.hash
+}
+
+// Visit calls the do function for each element of the set in numerical order
+// until do returns true or all elements are visited.
+func (f Frozen) Visit(do func(n int) bool) (aborted bool) {
+	return f.bs.Visit(do)
+}
+
+// Equal tells if the two sets have the same elements.
+func (f Frozen) Equal(other Frozen) bool {
+	return f.hash == other.hash && f.size == other.size && f.bs.Equal(other.bs)
+}
+
+// Subset tells if f is a subset of other.
+func (f Frozen) Subset(other Frozen) bool {
+	return f.size <= other.size && f.max <= other.max && f.bs.Subset(other.bs)
+}
+
+// Intersects tells if f and other have at least one element in common.
+func (f Frozen) Intersects(other Frozen) bool {
+	if !f.nonEmpty || !other.nonEmpty || f.max < other.min || other.max < f.min {
+		return false
+	}
+	return f.bs.Intersects(other.bs)
+}
+
+// BitSet returns a mutable copy of the set.
+func (f Frozen) BitSet() BitSet {
+	return f.bs.Copy()
+}
+
+// String returns a string representation of the set in the same format
+// as BitSet.String.
+func (f Frozen) String() string {
+	return f.bs.String()
+}
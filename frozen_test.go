@@ -0,0 +1,91 @@
+package bitset
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitSet_Freeze(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+	}{
+		{"empty", New()},
+		{"nil", nil},
+		{"single", New(5)},
+		{"multi word", New(1, 64, 200, 1000)},
+		{"untrimmed", BitSet{0b101, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.bs.Freeze()
+			require.Equal(t, tt.bs.Size(), f.Size())
+			require.Equal(t, tt.bs.Min(), f.Min())
+			require.Equal(t, tt.bs.Max(), f.Max())
+			require.Equal(t, tt.bs.Empty(), f.Empty())
+			require.Equal(t, tt.bs.String(), f.String())
+			require.Equal(t, len(f.bs), cap(f.bs), "storage is clipped")
+			require.Equal(t, f.bs.trimmedLen(), len(f.bs), "storage is trimmed")
+			require.True(t, f.Equal(tt.bs.Copy().Freeze()))
+		})
+	}
+}
+
+func TestFrozen_Isolation(t *testing.T) {
+	bs := New(1, 2, 100)
+	f := bs.Freeze()
+	bs.Add(500)
+	bs.Delete(1)
+
+	require.Equal(t, "{1 2 100}", f.String())
+	require.Equal(t, 3, f.Size())
+	require.Equal(t, 100, f.Max())
+
+	c := f.BitSet()
+	c.Add(7)
+	require.False(t, f.Contains(7))
+}
+
+func TestFrozen_Predicates(t *testing.T) {
+	a := New(1, 2, 3).Freeze()
+	b := New(1, 2, 3, 200).Freeze()
+	c := New(300, 400).Freeze()
+	var empty Frozen
+
+	require.True(t, a.Subset(b))
+	require.False(t, b.Subset(a))
+	require.True(t, empty.Subset(a))
+	require.True(t, a.Intersects(b))
+	require.False(t, a.Intersects(c))
+	require.False(t, empty.Intersects(a))
+	require.False(t, a.Equal(b))
+	require.True(t, a.Equal(New(3, 2, 1).Freeze()))
+	require.Equal(t, a.Hash(), New(1, 2, 3).Freeze().Hash())
+	require.NotEqual(t, a.Hash(), b.Hash())
+	require.Equal(t, uint64(0), empty.Hash())
+
+	var visited []int
+	b.Visit(func(n int) bool {
+		visited = append(visited, n)
+		return false
+	})
+	require.Equal(t, []int{1, 2, 3, 200}, visited)
+}
+
+func TestFrozen_Concurrent(t *testing.T) {
+	f := FromRange(0, 1000).Freeze()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for n := range 1000 {
+				if !f.Contains(n) {
+					t.Errorf("missing %d", n)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,67 @@
+package bitset
+
+import "math/bits"
+
+// Iterator walks the elements of a set in ascending order, remembering
+// its position between calls, so each step takes amortized O(1) time.
+// The iterator reads the words of the set it was created from; the results
+// are undefined if the set is changed while the iterator is in use.
+type Iterator struct {
+	bs BitSet
+	i  int    // index of the current word
+	w  uint64 // bits of the current word not yet returned
+}
+
+// Iterator returns an iterator positioned before the first element of bs.
+func (bs BitSet) Iterator() Iterator {
+	it := Iterator{bs: bs}
+	if len(bs) > 0 {
+		it.w = bs[0]
+	}
+	return it
+}
+
+// advance moves the iterator to the next non-zero word
+// and tells if there is one.
+func (it *Iterator) advance() bool {
+	for it.w == 0 {
+		if it.i+1 >= len(it.bs) {
+			it.i = len(it.bs)
+			return false
+		}
+		it.i++
+		it.w = it.bs[it.i]
+	}
+	return true
+}
+
+// Peek returns the next element without moving the iterator,
+// or false if there are no more elements.
+func (it *Iterator) Peek() (int, bool) {
+	if !it.advance() {
+		return -1, false
+	}
+	return (it.i << shift) + bits.TrailingZeros64(it.w), true
+}
+
+// Next returns the next element and moves the iterator past it,
+// or returns false if there are no more elements.
+func (it *Iterator) Next() (int, bool) {
+	n, ok := it.Peek()
+	if ok {
+		it.w &= it.w - 1 // clear the lowest set bit
+	}
+	return n, ok
+}
+
+// Seek moves the iterator so that the next element it returns is
+// the first element e, e ≥ m, of the set. Seeking backward is allowed.
+func (it *Iterator) Seek(m int) {
+	m = max(0, m)
+	it.i = m >> shift
+	if it.i >= len(it.bs) {
+		it.i, it.w = len(it.bs), 0
+		return
+	}
+	it.w = it.bs[it.i] >> uint(m&div64rem) << uint(m&div64rem) // zero out bits for numbers < m
+}
@@ -0,0 +1,84 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name string
+		bs   BitSet
+	}{
+		{"empty", New()},
+		{"nil", nil},
+		{"next prev fixture", New(0, 2, 63, 64, 100, 300)},
+		{"full words", FromRange(0, 192)},
+		{"untrimmed", BitSet{0, 0b1001, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int{}
+			it := tt.bs.Iterator()
+			for {
+				p, pok := it.Peek()
+				n, ok := it.Next()
+				require.Equal(t, pok, ok)
+				require.Equal(t, p, n)
+				if !ok {
+					break
+				}
+				got = append(got, n)
+			}
+			require.Equal(t, tt.bs.ToSlice(), got)
+
+			n, ok := it.Next()
+			require.False(t, ok, "exhausted iterator stays exhausted")
+			require.Equal(t, -1, n)
+		})
+	}
+}
+
+func TestIterator_Seek(t *testing.T) {
+	bs := New(0, 2, 63, 64, 100, 300)
+	elems := bs.ToSlice()
+	// oracle returns the index of the first element ≥ m.
+	oracle := func(m int) int {
+		i := 0
+		for i < len(elems) && elems[i] < m {
+			i++
+		}
+		return i
+	}
+
+	r := rand.New(rand.NewPCG(7, 8))
+	it := bs.Iterator()
+	pos := 0 // index in elems of the next element of it
+	for range 2000 {
+		if r.IntN(3) == 0 {
+			m := r.IntN(400) - 10
+			it.Seek(m)
+			pos = oracle(m)
+			continue
+		}
+		n, ok := it.Next()
+		if pos == len(elems) {
+			require.False(t, ok)
+			continue
+		}
+		require.True(t, ok)
+		require.Equal(t, elems[pos], n)
+		pos++
+	}
+
+	it.Seek(1000)
+	_, ok := it.Peek()
+	require.False(t, ok)
+	it.Seek(64)
+	n, ok := it.Next()
+	require.True(t, ok)
+	require.Equal(t, 64, n)
+}
@@ -0,0 +1,96 @@
+package bitset
+
+import "math/bits"
+
+// The word kernels below are the portable reference implementations
+// of the loops shared by the set operations. The functions without the
+// Generic suffix dispatch to an accelerated implementation where one
+// is available (see kernels_amd64.go) and can be disabled with the
+// purego build tag. In all binary kernels len(dst) must be at least len(src).
+
+// andWordsGeneric sets dst[i] &= src[i] for every i < len(src).
+func andWordsGeneric(dst, src []uint64) {
+	dst = dst[:len(src)]
+	for ; len(src) > 7; dst, src = dst[8:], src[8:] {
+		dst[0] &= src[0]
+		dst[1] &= src[1]
+		dst[2] &= src[2]
+		dst[3] &= src[3]
+		dst[4] &= src[4]
+		dst[5] &= src[5]
+		dst[6] &= src[6]
+		dst[7] &= src[7]
+	}
+	for i := range src {
+		dst[i] &= src[i]
+	}
+}
+
+// orWordsGeneric sets dst[i] |= src[i] for every i < len(src).
+func orWordsGeneric(dst, src []uint64) {
+	dst = dst[:len(src)]
+	for ; len(src) > 7; dst, src = dst[8:], src[8:] {
+		dst[0] |= src[0]
+		dst[1] |= src[1]
+		dst[2] |= src[2]
+		dst[3] |= src[3]
+		dst[4] |= src[4]
+		dst[5] |= src[5]
+		dst[6] |= src[6]
+		dst[7] |= src[7]
+	}
+	for i := range src {
+		dst[i] |= src[i]
+	}
+}
+
+// xorWordsGeneric sets dst[i] ^= src[i] for every i < len(src).
+func xorWordsGeneric(dst, src []uint64) {
+	dst = dst[:len(src)]
+	for ; len(src) > 7; dst, src = dst[8:], src[8:] {
+		dst[0] ^= src[0]
+		dst[1] ^= src[1]
+		dst[2] ^= src[2]
+		dst[3] ^= src[3]
+		dst[4] ^= src[4]
+		dst[5] ^= src[5]
+		dst[6] ^= src[6]
+		dst[7] ^= src[7]
+	}
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
+
+// andNotWordsGeneric sets dst[i] &^= src[i] for every i < len(src).
+func andNotWordsGeneric(dst, src []uint64) {
+	dst = dst[:len(src)]
+	for ; len(src) > 7; dst, src = dst[8:], src[8:] {
+		dst[0] &^= src[0]
+		dst[1] &^= src[1]
+		dst[2] &^= src[2]
+		dst[3] &^= src[3]
+		dst[4] &^= src[4]
+		dst[5] &^= src[5]
+		dst[6] &^= src[6]
+		dst[7] &^= src[7]
+	}
+	for i := range src {
+		dst[i] &^= src[i]
+	}
+}
+
+// popcntWordsGeneric returns the number of one bits in s.
+func popcntWordsGeneric(s []uint64) int {
+	var c0, c1, c2, c3 int
+	for ; len(s) > 3; s = s[4:] {
+		c0 += bits.OnesCount64(s[0])
+		c1 += bits.OnesCount64(s[1])
+		c2 += bits.OnesCount64(s[2])
+		c3 += bits.OnesCount64(s[3])
+	}
+	for _, w := range s {
+		c0 += bits.OnesCount64(w)
+	}
+	return c0 + c1 + c2 + c3
+}
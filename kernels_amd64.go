@@ -0,0 +1,78 @@
+//go:build !purego
+
+package bitset
+
+// asmMinWords is the operand length below which the generic kernels
+// are used, since the call overhead dominates for short operands.
+const asmMinWords = 16
+
+var (
+	// hasPOPCNT tells if the CPU supports the POPCNT instruction.
+	hasPOPCNT = cpuHasPOPCNT()
+	// hasAVX2 tells if the CPU and the OS support the AVX2 instructions.
+	hasAVX2 = cpuHasAVX2()
+)
+
+// The AVX2 kernels process n words of dst and src, 16 at a time.
+
+//go:noescape
+func andWordsAVX2(dst, src *uint64, n int)
+
+//go:noescape
+func orWordsAVX2(dst, src *uint64, n int)
+
+//go:noescape
+func xorWordsAVX2(dst, src *uint64, n int)
+
+//go:noescape
+func andNotWordsAVX2(dst, src *uint64, n int)
+
+//go:noescape
+func popcntWordsPOPCNT(s *uint64, n int) int
+
+func cpuHasPOPCNT() bool
+
+func cpuHasAVX2() bool
+
+func andWords(dst, src []uint64) {
+	if len(src) < asmMinWords || !hasAVX2 {
+		andWordsGeneric(dst, src)
+		return
+	}
+	_ = dst[len(src)-1]
+	andWordsAVX2(&dst[0], &src[0], len(src))
+}
+
+func orWords(dst, src []uint64) {
+	if len(src) < asmMinWords || !hasAVX2 {
+		orWordsGeneric(dst, src)
+		return
+	}
+	_ = dst[len(src)-1]
+	orWordsAVX2(&dst[0], &src[0], len(src))
+}
+
+func xorWords(dst, src []uint64) {
+	if len(src) < asmMinWords || !hasAVX2 {
+		xorWordsGeneric(dst, src)
+		return
+	}
+	_ = dst[len(src)-1]
+	xorWordsAVX2(&dst[0], &src[0], len(src))
+}
+
+func andNotWords(dst, src []uint64) {
+	if len(src) < asmMinWords || !hasAVX2 {
+		andNotWordsGeneric(dst, src)
+		return
+	}
+	_ = dst[len(src)-1]
+	andNotWordsAVX2(&dst[0], &src[0], len(src))
+}
+
+func popcntWords(s []uint64) int {
+	if len(s) < asmMinWords || !hasPOPCNT {
+		return popcntWordsGeneric(s)
+	}
+	return popcntWordsPOPCNT(&s[0], len(s))
+}
@@ -0,0 +1,268 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func andWordsAVX2(dst, src *uint64, n int)
+TEXT ·andWordsAVX2(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $16
+	JB   tail
+
+loop16:
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 0(SI), Y4
+	VMOVDQU 32(SI), Y5
+	VMOVDQU 64(SI), Y6
+	VMOVDQU 96(SI), Y7
+	VPAND Y4, Y0, Y0
+	VPAND Y5, Y1, Y1
+	VPAND Y6, Y2, Y2
+	VPAND Y7, Y3, Y3
+	VMOVDQU Y0, 0(DI)
+	VMOVDQU Y1, 32(DI)
+	VMOVDQU Y2, 64(DI)
+	VMOVDQU Y3, 96(DI)
+	ADDQ    $128, DI
+	ADDQ    $128, SI
+	SUBQ    $16, CX
+	CMPQ    CX, $16
+	JAE     loop16
+	VZEROUPPER
+
+tail:
+	TESTQ CX, CX
+	JZ    done
+
+loop1:
+	MOVQ (SI), AX
+	ANDQ AX, (DI)
+	ADDQ $8, DI
+	ADDQ $8, SI
+	DECQ CX
+	JNZ  loop1
+
+done:
+	RET
+
+// func orWordsAVX2(dst, src *uint64, n int)
+TEXT ·orWordsAVX2(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $16
+	JB   tail
+
+loop16:
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 0(SI), Y4
+	VMOVDQU 32(SI), Y5
+	VMOVDQU 64(SI), Y6
+	VMOVDQU 96(SI), Y7
+	VPOR Y4, Y0, Y0
+	VPOR Y5, Y1, Y1
+	VPOR Y6, Y2, Y2
+	VPOR Y7, Y3, Y3
+	VMOVDQU Y0, 0(DI)
+	VMOVDQU Y1, 32(DI)
+	VMOVDQU Y2, 64(DI)
+	VMOVDQU Y3, 96(DI)
+	ADDQ    $128, DI
+	ADDQ    $128, SI
+	SUBQ    $16, CX
+	CMPQ    CX, $16
+	JAE     loop16
+	VZEROUPPER
+
+tail:
+	TESTQ CX, CX
+	JZ    done
+
+loop1:
+	MOVQ (SI), AX
+	ORQ AX, (DI)
+	ADDQ $8, DI
+	ADDQ $8, SI
+	DECQ CX
+	JNZ  loop1
+
+done:
+	RET
+
+// func xorWordsAVX2(dst, src *uint64, n int)
+TEXT ·xorWordsAVX2(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $16
+	JB   tail
+
+loop16:
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 0(SI), Y4
+	VMOVDQU 32(SI), Y5
+	VMOVDQU 64(SI), Y6
+	VMOVDQU 96(SI), Y7
+	VPXOR Y4, Y0, Y0
+	VPXOR Y5, Y1, Y1
+	VPXOR Y6, Y2, Y2
+	VPXOR Y7, Y3, Y3
+	VMOVDQU Y0, 0(DI)
+	VMOVDQU Y1, 32(DI)
+	VMOVDQU Y2, 64(DI)
+	VMOVDQU Y3, 96(DI)
+	ADDQ    $128, DI
+	ADDQ    $128, SI
+	SUBQ    $16, CX
+	CMPQ    CX, $16
+	JAE     loop16
+	VZEROUPPER
+
+tail:
+	TESTQ CX, CX
+	JZ    done
+
+loop1:
+	MOVQ (SI), AX
+	XORQ AX, (DI)
+	ADDQ $8, DI
+	ADDQ $8, SI
+	DECQ CX
+	JNZ  loop1
+
+done:
+	RET
+
+// func andNotWordsAVX2(dst, src *uint64, n int)
+TEXT ·andNotWordsAVX2(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $16
+	JB   tail
+
+loop16:
+	VMOVDQU 0(DI), Y0
+	VMOVDQU 32(DI), Y1
+	VMOVDQU 64(DI), Y2
+	VMOVDQU 96(DI), Y3
+	VMOVDQU 0(SI), Y4
+	VMOVDQU 32(SI), Y5
+	VMOVDQU 64(SI), Y6
+	VMOVDQU 96(SI), Y7
+	VPANDN Y0, Y4, Y0
+	VPANDN Y1, Y5, Y1
+	VPANDN Y2, Y6, Y2
+	VPANDN Y3, Y7, Y3
+	VMOVDQU Y0, 0(DI)
+	VMOVDQU Y1, 32(DI)
+	VMOVDQU Y2, 64(DI)
+	VMOVDQU Y3, 96(DI)
+	ADDQ    $128, DI
+	ADDQ    $128, SI
+	SUBQ    $16, CX
+	CMPQ    CX, $16
+	JAE     loop16
+	VZEROUPPER
+
+tail:
+	TESTQ CX, CX
+	JZ    done
+
+loop1:
+	MOVQ (SI), AX
+	NOTQ AX
+	ANDQ AX, (DI)
+	ADDQ $8, DI
+	ADDQ $8, SI
+	DECQ CX
+	JNZ  loop1
+
+done:
+	RET
+
+// func popcntWordsPOPCNT(s *uint64, n int) int
+TEXT ·popcntWordsPOPCNT(SB), NOSPLIT, $0-24
+	MOVQ s+0(FP), SI
+	MOVQ n+8(FP), CX
+	XORQ AX, AX
+	XORQ BX, BX
+	XORQ DX, DX
+	XORQ R8, R8
+	CMPQ CX, $4
+	JB   tail
+
+loop4:
+	POPCNTQ 0(SI), R9
+	POPCNTQ 8(SI), R10
+	POPCNTQ 16(SI), R11
+	POPCNTQ 24(SI), R12
+	ADDQ    R9, AX
+	ADDQ    R10, BX
+	ADDQ    R11, DX
+	ADDQ    R12, R8
+	ADDQ    $32, SI
+	SUBQ    $4, CX
+	CMPQ    CX, $4
+	JAE     loop4
+
+tail:
+	TESTQ CX, CX
+	JZ    done
+
+loop1:
+	POPCNTQ (SI), R9
+	ADDQ    R9, AX
+	ADDQ    $8, SI
+	DECQ    CX
+	JNZ     loop1
+
+done:
+	ADDQ BX, AX
+	ADDQ DX, AX
+	ADDQ R8, AX
+	MOVQ AX, ret+16(FP)
+	RET
+
+// func cpuHasPOPCNT() bool
+TEXT ·cpuHasPOPCNT(SB), NOSPLIT, $0-1
+	MOVL $1, AX
+	XORL CX, CX
+	CPUID
+	SHRL $23, CX
+	ANDL $1, CX
+	MOVB CX, ret+0(FP)
+	RET
+
+// func cpuHasAVX2() bool
+TEXT ·cpuHasAVX2(SB), NOSPLIT, $0-1
+	MOVB $0, ret+0(FP)
+	MOVL $1, AX
+	XORL CX, CX
+	CPUID
+	ANDL $0x08000000, CX // OSXSAVE
+	JZ   no
+	XORL CX, CX
+	XGETBV
+	ANDL $6, AX // the OS saves the XMM and YMM registers
+	CMPL AX, $6
+	JNE  no
+	MOVL $7, AX
+	XORL CX, CX
+	CPUID
+	SHRL $5, BX // AVX2
+	ANDL $1, BX
+	MOVB BX, ret+0(FP)
+
+no:
+	RET
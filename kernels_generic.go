@@ -0,0 +1,9 @@
+//go:build !amd64 || purego
+
+package bitset
+
+func andWords(dst, src []uint64)    { andWordsGeneric(dst, src) }
+func orWords(dst, src []uint64)     { orWordsGeneric(dst, src) }
+func xorWords(dst, src []uint64)    { xorWordsGeneric(dst, src) }
+func andNotWords(dst, src []uint64) { andNotWordsGeneric(dst, src) }
+func popcntWords(s []uint64) int    { return popcntWordsGeneric(s) }
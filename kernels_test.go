@@ -0,0 +1,57 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKernels(t *testing.T) {
+	r := rand.New(rand.NewPCG(13, 14))
+	randomWords := func(n int) []uint64 {
+		s := make([]uint64, n)
+		for i := range s {
+			s[i] = r.Uint64() & r.Uint64()
+		}
+		return s
+	}
+	binary := []struct {
+		name          string
+		fast, generic func(dst, src []uint64)
+		want          func(a, b uint64) uint64
+	}{
+		{"and", andWords, andWordsGeneric, func(a, b uint64) uint64 { return a & b }},
+		{"or", orWords, orWordsGeneric, func(a, b uint64) uint64 { return a | b }},
+		{"xor", xorWords, xorWordsGeneric, func(a, b uint64) uint64 { return a ^ b }},
+		{"andNot", andNotWords, andNotWordsGeneric, func(a, b uint64) uint64 { return a &^ b }},
+	}
+	lengths := []int{0, 1, 7, 8, 9, 15, 16, 17, 31, 32, 33, 47, 64, 100, 1000}
+	for range 10 {
+		lengths = append(lengths, r.IntN(5000))
+	}
+
+	for _, n := range lengths {
+		for _, op := range binary {
+			dst, src := randomWords(n+r.IntN(3)), randomWords(n)
+			want := append([]uint64(nil), dst...)
+			for i, w := range src {
+				want[i] = op.want(want[i], w)
+			}
+			fast, generic := append([]uint64(nil), dst...), append([]uint64(nil), dst...)
+			op.fast(fast, src)
+			op.generic(generic, src)
+			require.Equal(t, want, fast, "%s of %d words", op.name, n)
+			require.Equal(t, want, generic, "generic %s of %d words", op.name, n)
+		}
+		s := randomWords(n)
+		require.Equal(t, popcntWordsGeneric(s), popcntWords(s), "popcnt of %d words", n)
+		naive := 0
+		for _, w := range s {
+			for ; w != 0; w &= w - 1 {
+				naive++
+			}
+		}
+		require.Equal(t, naive, popcntWordsGeneric(s))
+	}
+}
@@ -0,0 +1,116 @@
+package bitset
+
+import "fmt"
+
+// Matrix is a matrix of bits with a fixed number of rows and columns,
+// stored row by row in a single BitSet, so that the bit at row r and
+// column c is the element r*cols+c. It suits adjacency matrices of graphs,
+// with Row giving the successors of a node. The methods panic if a row or
+// a column is out of range; elements of other sets that don't fit in a row
+// are ignored. The zero value of Matrix is a matrix with no rows.
+type Matrix struct {
+	bs         BitSet
+	rows, cols int
+}
+
+// NewMatrix creates a new matrix of zero bits with the given size.
+// It panics if rows or cols is negative or if the matrix has more than
+// MaxElement+1 bits.
+func NewMatrix(rows, cols int) Matrix {
+	if rows < 0 || cols < 0 {
+		panic(fmt.Errorf("bitset: negative matrix size %dx%d", rows, cols))
+	}
+	if limit := uint(maxElement) + 1; cols > 0 && uint(rows) > limit/uint(cols) {
+		panic(fmt.Errorf("bitset: matrix size %dx%d exceeds the maximum of %d bits", rows, cols, limit))
+	}
+	return Matrix{rows: rows, cols: cols}
+}
+
+// Rows returns the number of rows of m.
+func (m Matrix) Rows() int {
+	return m.rows
+}
+
+// Cols returns the number of columns of m.
+func (m Matrix) Cols() int {
+	return m.cols
+}
+
+// checkRow panics if r is not a row of m.
+func (m Matrix) checkRow(r int) {
+	if uint(r) >= uint(m.rows) {
+		panic(fmt.Errorf("bitset: matrix row %d out of range [0, %d)", r, m.rows))
+	}
+}
+
+// index returns the element of m.bs at row r and column c,
+// and panics if they are out of range.
+func (m Matrix) index(r, c int) int {
+	if uint(r) >= uint(m.rows) || uint(c) >= uint(m.cols) {
+		panic(fmt.Errorf("bitset: matrix index (%d, %d) out of range for %dx%d matrix", r, c, m.rows, m.cols))
+	}
+	return r*m.cols + c
+}
+
+// Set sets the bit at row r and column c.
+func (m *Matrix) Set(r, c int) {
+	m.bs.Add(m.index(r, c))
+}
+
+// Clear clears the bit at row r and column c.
+func (m *Matrix) Clear(r, c int) {
+	m.bs.Delete(m.index(r, c))
+}
+
+// Contains tells if the bit at row r and column c is set.
+func (m Matrix) Contains(r, c int) bool {
+	return m.bs.Contains(m.index(r, c))
+}
+
+// Row creates a new set with the columns of the set bits in row r.
+func (m Matrix) Row(r int) BitSet {
+	m.checkRow(r)
+	return m.bs.Window(r*m.cols, (r+1)*m.cols)
+}
+
+// VisitRow calls the do function for the column of each set bit in row r
+// in numerical order with the same abort semantics as BitSet.Visit.
+func (m Matrix) VisitRow(r int, do func(c int) bool) (aborted bool) {
+	m.checkRow(r)
+	base := r * m.cols
+	return m.bs.VisitRange(base, base+m.cols, func(n int) bool {
+		return do(n - base)
+	})
+}
+
+// OrRow sets the bits in row r at the columns that are elements of other.
+// The elements of other that are not less than Cols() are ignored.
+func (m *Matrix) OrRow(r int, other BitSet) {
+	m.checkRow(r)
+	top := other.Floor(m.cols - 1)
+	if top < 0 {
+		return
+	}
+	base := r * m.cols
+	if n := (base+top)>>shift + 1; n > len(m.bs) {
+		m.bs.resize(n)
+	}
+	// Shift the words of other to the start of the row, which is at
+	// the bit off of the word i0.
+	i0, off := base>>shift, uint(base&div64rem)
+	last := top >> shift
+	for i, w := range other[:last+1] {
+		if i == last {
+			w &= bitMask(0, top&div64rem)
+		}
+		m.bs[i0+i] |= w << off
+		if hi := w >> (bpw - off); hi != 0 {
+			m.bs[i0+i+1] |= hi
+		}
+	}
+}
+
+// BitSet returns a copy of the bits of m as a set of the elements r*cols+c.
+func (m Matrix) BitSet() BitSet {
+	return m.bs.Copy()
+}
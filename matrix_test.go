@@ -0,0 +1,134 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrix(t *testing.T) {
+	m := NewMatrix(3, 70)
+	require.Equal(t, 3, m.Rows())
+	require.Equal(t, 70, m.Cols())
+	require.True(t, m.BitSet().Empty())
+
+	m.Set(0, 0)
+	m.Set(1, 69)
+	m.Set(2, 5)
+	m.Set(2, 5)
+	require.True(t, m.Contains(1, 69))
+	require.False(t, m.Contains(1, 68))
+	require.Equal(t, New(0, 70+69, 140+5), m.BitSet())
+	require.Equal(t, New(69), m.Row(1))
+	require.Equal(t, New(5), m.Row(2))
+
+	m.Clear(1, 69)
+	m.Clear(1, 0)
+	require.True(t, m.Row(1).Empty())
+	require.Equal(t, New(0, 145), m.BitSet())
+
+	// Elements beyond the last column are ignored.
+	m.OrRow(1, New(1, 63, 64, 69, 70, 1000))
+	require.Equal(t, New(1, 63, 64, 69), m.Row(1))
+	require.Equal(t, New(5), m.Row(2))
+	m.OrRow(1, New(70))
+	m.OrRow(1, nil)
+	require.Equal(t, New(1, 63, 64, 69), m.Row(1))
+
+	var cols []int
+	require.False(t, m.VisitRow(1, func(c int) bool {
+		cols = append(cols, c)
+		return false
+	}))
+	require.Equal(t, []int{1, 63, 64, 69}, cols)
+	require.True(t, m.VisitRow(1, func(c int) bool { return c == 63 }))
+
+	require.PanicsWithError(t, "bitset: matrix index (3, 0) out of range for 3x70 matrix",
+		func() { m.Set(3, 0) })
+	require.PanicsWithError(t, "bitset: matrix index (0, 70) out of range for 3x70 matrix",
+		func() { m.Contains(0, 70) })
+	require.PanicsWithError(t, "bitset: matrix index (-1, 0) out of range for 3x70 matrix",
+		func() { m.Clear(-1, 0) })
+	require.PanicsWithError(t, "bitset: matrix row 3 out of range [0, 3)",
+		func() { m.Row(3) })
+	require.PanicsWithError(t, "bitset: matrix row -1 out of range [0, 3)",
+		func() { m.OrRow(-1, New(1)) })
+	require.PanicsWithError(t, "bitset: negative matrix size -1x2",
+		func() { NewMatrix(-1, 2) })
+
+	maxElement = 1000
+	t.Cleanup(func() { maxElement = MaxElement })
+	require.PanicsWithError(t, "bitset: matrix size 11x100 exceeds the maximum of 1001 bits",
+		func() { NewMatrix(11, 100) })
+	require.NotPanics(t, func() { NewMatrix(10, 100) })
+	require.NotPanics(t, func() { NewMatrix(5000, 0) })
+}
+
+func TestMatrix_OrRow(t *testing.T) {
+	r := rand.New(rand.NewPCG(15, 16))
+	for range 200 {
+		rows, cols := 1+r.IntN(5), r.IntN(200)
+		m := NewMatrix(rows, cols)
+		want := make([]BitSet, rows)
+		for range 10 {
+			row := r.IntN(rows)
+			var other BitSet
+			for range r.IntN(20) {
+				other.Add(r.IntN(250))
+			}
+			m.OrRow(row, other)
+			want[row].Or(other)
+			want[row].TruncateAt(cols)
+			for i := range rows {
+				require.True(t, want[i].Equal(m.Row(i)), "row %d of %dx%d", i, rows, cols)
+			}
+			require.NoError(t, m.bs.Validate())
+		}
+	}
+}
+
+// TestMatrix_TransitiveClosure computes the reachability matrix of a graph
+// with Warshall's algorithm: if i reaches k, i reaches every node k reaches.
+func TestMatrix_TransitiveClosure(t *testing.T) {
+	const n = 70 // rows span word boundaries
+	edges := [][2]int{
+		{0, 1}, {1, 2}, {2, 0}, // cycle
+		{2, 3}, {3, 65}, {65, 69},
+		{10, 11}, {11, 64},
+		{69, 68},
+	}
+	m := NewMatrix(n, n)
+	for _, e := range edges {
+		m.Set(e[0], e[1])
+	}
+	for k := range n {
+		for i := range n {
+			if m.Contains(i, k) {
+				m.OrRow(i, m.Row(k))
+			}
+		}
+	}
+
+	// Check against a depth-first search from every node.
+	adj := make([][]int, n)
+	for _, e := range edges {
+		adj[e[0]] = append(adj[e[0]], e[1])
+	}
+	for i := range n {
+		var reach BitSet
+		stack := append([]int(nil), adj[i]...)
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !reach.TestAndSet(v) {
+				stack = append(stack, adj[v]...)
+			}
+		}
+		require.True(t, reach.Equal(m.Row(i)), "row %d: %v, want %v", i, m.Row(i), reach)
+	}
+	require.Equal(t, New(0, 1, 2, 3, 65, 68, 69), m.Row(0))
+	require.Equal(t, New(11, 64), m.Row(10))
+	require.Equal(t, New(68), m.Row(69))
+	require.True(t, m.Row(68).Empty())
+}
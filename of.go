@@ -0,0 +1,103 @@
+package bitset
+
+// Integer is a constraint that permits any integer type.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Of is a set of non-negative integers of type T, such as typed enums.
+// It is a thin wrapper over BitSet that rejects elements of other types at
+// compile time. Elements whose value is not a non-negative int are ignored.
+// The zero value of Of is an empty set.
+type Of[T Integer] BitSet
+
+// NewOf creates a new set with the given non-negative elements.
+func NewOf[T Integer](n ...T) Of[T] {
+	var s Of[T]
+	s.Add(n...)
+	return s
+}
+
+// Add adds the given elements to s, skipping negative ones.
+func (s *Of[T]) Add(n ...T) {
+	for _, e := range n {
+		(*BitSet)(s).Add(int(e))
+	}
+}
+
+// Delete removes the given elements from s, skipping negative
+// and absent ones.
+func (s *Of[T]) Delete(n ...T) {
+	for _, e := range n {
+		(*BitSet)(s).Delete(int(e))
+	}
+}
+
+// Contains tells if n is in the set.
+func (s Of[T]) Contains(n T) bool {
+	return BitSet(s).Contains(int(n))
+}
+
+// Size returns the number of elements in the set.
+func (s Of[T]) Size() int {
+	return BitSet(s).Size()
+}
+
+// Empty tells if the set is empty.
+func (s Of[T]) Empty() bool {
+	return BitSet(s).Empty()
+}
+
+// Equal tells if s and other are equal.
+func (s Of[T]) Equal(other Of[T]) bool {
+	return BitSet(s).Equal(BitSet(other))
+}
+
+// Visit calls the do function for each element of s in numerical order
+// with the same semantics as BitSet.Visit.
+func (s Of[T]) Visit(do func(n T) bool) (aborted bool) {
+	return BitSet(s).Visit(func(n int) bool {
+		return do(T(n))
+	})
+}
+
+// ToSlice returns the elements of the set in ascending order.
+func (s Of[T]) ToSlice() []T {
+	elems := make([]T, 0, s.Size())
+	BitSet(s).VisitAll(func(n int) {
+		elems = append(elems, T(n))
+	})
+	return elems
+}
+
+// And keeps only the elements set in both *s and other.
+func (s *Of[T]) And(other Of[T]) {
+	(*BitSet)(s).And(BitSet(other))
+}
+
+// Or adds the elements set in other to *s.
+func (s *Of[T]) Or(other Of[T]) {
+	(*BitSet)(s).Or(BitSet(other))
+}
+
+// Xor toggles the elements set in other in *s.
+func (s *Of[T]) Xor(other Of[T]) {
+	(*BitSet)(s).Xor(BitSet(other))
+}
+
+// AndNot removes the elements set in other from *s.
+func (s *Of[T]) AndNot(other Of[T]) {
+	(*BitSet)(s).AndNot(BitSet(other))
+}
+
+// BitSet returns s as a plain BitSet sharing the same words.
+func (s Of[T]) BitSet() BitSet {
+	return BitSet(s)
+}
+
+// String returns a string representation of the set in the same format
+// as BitSet.String.
+func (s Of[T]) String() string {
+	return BitSet(s).String()
+}
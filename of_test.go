@@ -0,0 +1,64 @@
+package bitset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type permBit int
+
+const (
+	permRead permBit = iota
+	permWrite
+	permExec
+	permAdmin permBit = 64
+)
+
+func TestOf(t *testing.T) {
+	var p Of[permBit]
+	require.True(t, p.Empty())
+	p.Add(permRead, permExec, permAdmin, -1)
+	require.Equal(t, "{0 2 64}", p.String())
+	require.Equal(t, 3, p.Size())
+	require.True(t, p.Contains(permExec))
+	require.False(t, p.Contains(permWrite))
+	require.Equal(t, []permBit{permRead, permExec, permAdmin}, p.ToSlice())
+
+	p.Delete(permAdmin)
+	require.Equal(t, "{0 2}", p.String())
+	require.Len(t, p.BitSet(), 1)
+
+	var visited []permBit
+	p.Visit(func(n permBit) bool {
+		visited = append(visited, n)
+		return false
+	})
+	require.Equal(t, []permBit{permRead, permExec}, visited)
+
+	var u Of[uint8]
+	u.Add(255)
+	require.Equal(t, "{255}", u.String())
+}
+
+func TestOf_Ops(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     func(s *Of[permBit], other Of[permBit])
+		expect string
+	}{
+		{"and", (*Of[permBit]).And, "{1}"},
+		{"or", (*Of[permBit]).Or, "{0..2 64}"},
+		{"xor", (*Of[permBit]).Xor, "{0 2 64}"},
+		{"and not", (*Of[permBit]).AndNot, "{0}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOf(permRead, permWrite)
+			tt.op(&s, NewOf(permWrite, permExec, permAdmin))
+			require.Equal(t, tt.expect, s.String())
+			require.True(t, s.Equal(s))
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package bitset
+
+import "fmt"
+
+// Offset is a set of integers, including negative ones, represented as
+// a BitSet of the distances of the elements from a base. The base is kept
+// at the word containing the minimum element, so the memory cost is
+// proportional to Max()-Min() rather than to Max(). The zero value of Offset
+// is an empty set.
+type Offset struct {
+	base int // a multiple of 64, the element corresponding to bit 0 of bs
+	bs   BitSet
+}
+
+// NewOffset creates a new set with the given elements.
+func NewOffset(n ...int) Offset {
+	var s Offset
+	s.Add(n...)
+	return s
+}
+
+// alignDown returns the greatest multiple of 64 that is not greater than n.
+func alignDown(n int) int {
+	return n &^ div64rem
+}
+
+// rebase moves the base of s down to base, a multiple of 64 not greater than s.base.
+func (s *Offset) rebase(base int) {
+	if len(s.bs) == 0 {
+		s.base = base
+		return
+	}
+	s.bs.ShiftLeft(s.base - base)
+	s.base = base
+}
+
+// normalize moves the base of s up to the first non-zero word.
+func (s *Offset) normalize() {
+	i := 0
+	for i < len(s.bs) && s.bs[i] == 0 {
+		i++
+	}
+	if i == len(s.bs) {
+		s.bs.Reset()
+		s.base = 0
+		return
+	}
+	if i > 0 {
+		s.bs.ShiftRight(i << shift)
+		s.base += i << shift
+	}
+}
+
+// span returns the distance from base to n, n ≥ base, and panics
+// if it is greater than MaxElement, including when n-base overflows.
+func span(base, n int) int {
+	d := uint(n) - uint(base)
+	if d > uint(maxElement) {
+		panic(fmt.Errorf("bitset: offset span from %d to %d exceeds the maximum of %d", base, n, maxElement))
+	}
+	return int(d)
+}
+
+// Add adds the given elements to s, rebasing s when an element
+// is less than the current minimum. Add panics if the distance from
+// the base to the maximum element would exceed MaxElement.
+func (s *Offset) Add(n ...int) {
+	for _, e := range n {
+		if len(s.bs) == 0 || e < s.base {
+			base := alignDown(e)
+			if m, ok := s.Max(); ok {
+				span(base, m)
+			}
+			s.rebase(base)
+		}
+		s.bs.Add(span(s.base, e))
+	}
+}
+
+// Delete removes the given elements from s, skipping absent ones.
+func (s *Offset) Delete(n ...int) {
+	for _, e := range n {
+		if e >= s.base {
+			s.bs.Delete(e - s.base)
+		}
+	}
+	s.normalize()
+}
+
+// Contains tells if n is in the set.
+func (s Offset) Contains(n int) bool {
+	return n >= s.base && s.bs.Contains(n-s.base)
+}
+
+// Size returns the number of elements in the set.
+func (s Offset) Size() int {
+	return s.bs.Size()
+}
+
+// Empty tells if the set is empty.
+func (s Offset) Empty() bool {
+	return s.bs.Empty()
+}
+
+// Min returns the minimum element of the set, or false if the set is empty.
+func (s Offset) Min() (int, bool) {
+	if s.bs.Empty() {
+		return 0, false
+	}
+	return s.base + s.bs.Min(), true
+}
+
+// Max returns the maximum element of the set, or false if the set is empty.
+func (s Offset) Max() (int, bool) {
+	if s.bs.Empty() {
+		return 0, false
+	}
+	return s.base + s.bs.Max(), true
+}
+
+// Visit calls the do function for each element of s in numerical order
+// with the same semantics as BitSet.Visit.
+func (s Offset) Visit(do func(n int) bool) (aborted bool) {
+	return s.bs.Visit(func(n int) bool {
+		return do(s.base + n)
+	})
+}
+
+// Or adds all elements of other to s. Or panics if the distance from
+// the base to the maximum element would exceed MaxElement.
+func (s *Offset) Or(other Offset) {
+	if other.bs.Empty() {
+		return
+	}
+	if m, ok := s.Max(); ok {
+		om, _ := other.Max()
+		span(min(s.base, other.base), max(m, om))
+	}
+	if s.bs.Empty() || other.base < s.base {
+		s.rebase(other.base)
+	}
+	offset := (other.base - s.base) >> shift
+	if n := offset + len(other.bs); n > len(s.bs) {
+		s.bs.resize(n)
+	}
+	for i, w := range other.bs {
+		s.bs[offset+i] |= w
+	}
+	s.bs.trim()
+}
+
+// And keeps only the elements of s that are also in other.
+func (s *Offset) And(other Offset) {
+	offset := (s.base - other.base) >> shift // index of the first word of s in other
+	for i := range s.bs {
+		if j := offset + i; j >= 0 && j < len(other.bs) {
+			s.bs[i] &= other.bs[j]
+		} else {
+			s.bs[i] = 0
+		}
+	}
+	s.bs.trim()
+	s.normalize()
+}
+
+// String returns a string representation of the set in the same format
+// as BitSet.String.
+func (s Offset) String() string {
+	buf := []byte{'{'}
+	for a, b := range s.bs.Ranges() {
+		if len(buf) > 1 {
+			buf = append(buf, ' ')
+		}
+		buf = appendRange(buf, s.base+a, s.base+b)
+	}
+	return string(append(buf, '}'))
+}
@@ -0,0 +1,142 @@
+package bitset
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffset(t *testing.T) {
+	tests := []struct {
+		name   string
+		elems  []int
+		expect string
+		words  int
+	}{
+		{"empty", nil, "{}", 0},
+		{"negative", []int{-1, -2, -65}, "{-65 -2 -1}", 2},
+		{"sparse huge", []int{1_000_000, 1_000_003}, "{1000000 1000003}", 1},
+		{"crossing zero", []int{-1, 0, 1}, "{-1..1}", 2},
+		{"rebase down", []int{1000, 10, 500}, "{10 500 1000}", 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOffset(tt.elems...)
+			require.Equal(t, tt.expect, s.String())
+			require.Len(t, s.bs, tt.words)
+			require.Equal(t, len(tt.elems), s.Size())
+			for _, e := range tt.elems {
+				require.True(t, s.Contains(e))
+				require.False(t, s.Contains(e+1000))
+			}
+		})
+	}
+}
+
+func TestOffset_MinMax(t *testing.T) {
+	var s Offset
+	_, ok := s.Min()
+	require.False(t, ok)
+	_, ok = s.Max()
+	require.False(t, ok)
+
+	s.Add(100, -100, 5)
+	minElem, ok := s.Min()
+	require.True(t, ok)
+	require.Equal(t, -100, minElem)
+	maxElem, ok := s.Max()
+	require.True(t, ok)
+	require.Equal(t, 100, maxElem)
+}
+
+func TestOffset_Span(t *testing.T) {
+	s := NewOffset(-10)
+	require.PanicsWithError(t,
+		fmt.Sprintf("bitset: offset span from -64 to %d exceeds the maximum of %d", math.MaxInt-5, MaxElement),
+		func() { s.Add(math.MaxInt - 5) })
+	require.False(t, s.Contains(math.MaxInt-5))
+	require.Equal(t, "{-10}", s.String())
+
+	s = NewOffset(math.MaxInt - 5)
+	require.PanicsWithError(t,
+		fmt.Sprintf("bitset: offset span from -64 to %d exceeds the maximum of %d", math.MaxInt-5, MaxElement),
+		func() { s.Add(-10) })
+	require.PanicsWithError(t,
+		fmt.Sprintf("bitset: offset span from -64 to %d exceeds the maximum of %d", math.MaxInt-5, MaxElement),
+		func() { s.Or(NewOffset(-10)) })
+	require.Equal(t, fmt.Sprintf("{%d}", math.MaxInt-5), s.String())
+
+	maxElement = 1000
+	t.Cleanup(func() { maxElement = MaxElement })
+	s = NewOffset(-10, 936)
+	require.Equal(t, "{-10 936}", s.String())
+	require.PanicsWithError(t, "bitset: offset span from -64 to 937 exceeds the maximum of 1000",
+		func() { s.Add(937) })
+	require.PanicsWithError(t, "bitset: offset span from -128 to 936 exceeds the maximum of 1000",
+		func() { s.Add(-65) })
+	require.PanicsWithError(t, "bitset: offset span from -64 to 2000 exceeds the maximum of 1000",
+		func() { s.Or(NewOffset(2000)) })
+	require.Equal(t, "{-10 936}", s.String())
+}
+
+func TestOffset_Delete(t *testing.T) {
+	s := NewOffset(-200, 5, 1_000_000)
+	s.Delete(-200, 7, -1_000)
+	require.Equal(t, "{5 1000000}", s.String())
+	require.Equal(t, 0, s.base, "base moves up to the new minimum")
+
+	s.Delete(5)
+	require.Equal(t, "{1000000}", s.String())
+	require.Len(t, s.bs, 1)
+
+	s.Delete(1_000_000)
+	require.True(t, s.Empty())
+	require.False(t, s.Contains(1_000_000))
+}
+
+func TestOffset_Visit(t *testing.T) {
+	s := NewOffset(-65, -1, 3, 200)
+	var visited []int
+	s.Visit(func(n int) bool {
+		visited = append(visited, n)
+		return n == 3
+	})
+	require.Equal(t, []int{-65, -1, 3}, visited)
+}
+
+func TestOffset_Ops(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []int
+		or   string
+		and  string
+	}{
+		{"both empty", nil, nil, "{}", "{}"},
+		{"a empty", nil, []int{-5, 5}, "{-5 5}", "{}"},
+		{"b empty", []int{-5, 5}, nil, "{-5 5}", "{}"},
+		{"same base", []int{1, 2}, []int{2, 3}, "{1..3}", "{2}"},
+		{"b below a", []int{1000, 1100}, []int{-100, 1000}, "{-100 1000 1100}", "{1000}"},
+		{"b above a", []int{-100, 1000}, []int{1000, 1100}, "{-100 1000 1100}", "{1000}"},
+		{"partial overlap", []int{0, 100, 200, 300}, []int{150, 200, 250, 300, 350}, "{0 100 150 200 250 300 350}", "{200 300}"},
+		{"disjoint bases", []int{1_000_000}, []int{-1_000_000}, "{-1000000 1000000}", "{}"},
+		{"contained", []int{-64, 0, 64, 128}, []int{0, 64}, "{-64 0 64 128}", "{0 64}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOffset(tt.a...)
+			s.Or(NewOffset(tt.b...))
+			require.Equal(t, tt.or, s.String())
+
+			s = NewOffset(tt.a...)
+			s.And(NewOffset(tt.b...))
+			require.Equal(t, tt.and, s.String())
+			if minElem, ok := s.Min(); ok {
+				require.Equal(t, alignDown(minElem), s.base)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package bitset
+
+import "sync"
+
+// poolMaxWords is the capacity in words above which Put drops a set
+// instead of pooling it, so that the pool doesn't pin huge arrays.
+const poolMaxWords = 1 << 12
+
+var pool = sync.Pool{
+	New: func() any { return new(BitSet) },
+}
+
+// Get returns an empty set from a package-level pool. Its storage may be
+// reused from a set previously passed to Put, which makes it a suitable
+// destination for the *To variants of the set operations (AndTo, OrTo,
+// XorTo, AndNotTo) and for CopyTo.
+//
+// The caller owns the returned set until it passes it to Put.
+// Sets obtained from Get don't have to be returned to the pool.
+func Get() *BitSet {
+	return pool.Get().(*BitSet)
+}
+
+// Put empties bs and returns it to the pool used by Get. Sets with
+// a capacity larger than 4096 words (32 KiB) are dropped instead.
+//
+// After Put, neither bs nor any copy of *bs may be used by the caller,
+// since the storage can be handed out by Get at any moment. Put is a no-op
+// if bs is nil.
+func Put(bs *BitSet) {
+	if bs == nil || cap(*bs) > poolMaxWords {
+		return
+	}
+	bs.Reset()
+	pool.Put(bs)
+}
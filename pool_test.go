@@ -0,0 +1,30 @@
+package bitset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	bs := Get()
+	require.True(t, bs.Empty())
+	AndTo(bs, New(1, 2, 300), New(2, 300, 400))
+	require.Equal(t, "{2 300}", bs.String())
+	Put(bs)
+
+	for range 10 {
+		bs := Get()
+		require.True(t, bs.Empty())
+		require.NoError(t, bs.Validate())
+		bs.Or(New(7))
+		bs.Add(5, 1000)
+		require.Equal(t, "{5 7 1000}", bs.String())
+		Put(bs)
+	}
+
+	huge := FromRange(0, (poolMaxWords+1)*bpw)
+	Put(&huge)
+	require.LessOrEqual(t, cap(*Get()), poolMaxWords)
+	Put(nil)
+}
@@ -0,0 +1,69 @@
+package bitset
+
+import (
+	"math/bits"
+	"math/rand/v2"
+)
+
+// intN returns a random int in [0, n) from r, or from the package-level
+// source of math/rand/v2 if r is nil.
+func intN(r *rand.Rand, n int) int {
+	if r == nil {
+		return rand.IntN(n)
+	}
+	return r.IntN(n)
+}
+
+// Random returns an element of bs chosen uniformly at random using r,
+// or -1 if bs is empty. If r is nil, the package-level source of
+// math/rand/v2 is used.
+func (bs BitSet) Random(r *rand.Rand) int {
+	chosen, size := -1, 0
+	for i, w := range bs {
+		c := bits.OnesCount64(w)
+		if c == 0 {
+			continue
+		}
+		size += c
+		if k := intN(r, size); k < c {
+			chosen = i<<shift + selectInWord(w, k)
+		}
+	}
+	return chosen
+}
+
+// Sample creates a new set of min(k, Size()) elements of bs chosen
+// uniformly at random using r; every subset of that size is equally likely.
+// If r is nil, the package-level source of math/rand/v2 is used.
+func (bs BitSet) Sample(r *rand.Rand, k int) BitSet {
+	size := bs.Size()
+	if k <= 0 || size == 0 {
+		return BitSet{}
+	}
+	if k >= size {
+		return bs[:bs.trimmedLen()].Copy()
+	}
+	var ranks BitSet
+	ranks.Grow(size)
+	for j := size - k; j < size; j++ {
+		if t := intN(r, j+1); !ranks.TestAndSet(t) {
+			continue
+		}
+		ranks.Add(j)
+	}
+
+	s := make(BitSet, len(bs))
+	i, base := 0, 0 // current word of bs and the rank of its lowest element
+	c := bits.OnesCount64(bs[0])
+	ranks.Visit(func(rank int) bool {
+		for rank >= base+c {
+			base += c
+			i++
+			c = bits.OnesCount64(bs[i])
+		}
+		s[i] |= 1 << uint(selectInWord(bs[i], rank-base))
+		return false
+	})
+	s.trim()
+	return s
+}
@@ -0,0 +1,75 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitSet_Random(t *testing.T) {
+	require.Equal(t, -1, New().Random(nil))
+	require.Equal(t, -1, BitSet{0}.Random(rand.New(rand.NewPCG(1, 1))))
+	require.Equal(t, 70, New(70).Random(nil))
+
+	bs := New(1, 5, 63, 64, 100, 300, 301, 1000)
+	draw := func(seed uint64) []int {
+		r := rand.New(rand.NewPCG(seed, seed))
+		s := make([]int, 20)
+		for i := range s {
+			s[i] = bs.Random(r)
+		}
+		return s
+	}
+	require.Equal(t, draw(42), draw(42), "deterministic with a seeded source")
+
+	// Every element is expected 10000 times; allow a loose 10% deviation.
+	const draws = 80000
+	counts := map[int]int{}
+	r := rand.New(rand.NewPCG(3, 7))
+	for range draws {
+		e := bs.Random(r)
+		require.True(t, bs.Contains(e))
+		counts[e]++
+	}
+	require.Len(t, counts, bs.Size())
+	for e, c := range counts {
+		require.InDelta(t, draws/bs.Size(), c, float64(draws/bs.Size()/10), "element %d", e)
+	}
+	require.True(t, bs.Contains(bs.Random(nil)))
+}
+
+func TestBitSet_Sample(t *testing.T) {
+	bs := New(1, 5, 63, 64, 100, 300, 301, 1000)
+	r := rand.New(rand.NewPCG(1, 2))
+	require.Equal(t, BitSet{}, New().Sample(r, 3))
+	require.Equal(t, BitSet{}, bs.Sample(r, 0))
+	require.Equal(t, BitSet{}, bs.Sample(r, -1))
+	require.Equal(t, bs, bs.Sample(r, 8))
+	require.Equal(t, bs, append(bs.Copy(), 0).Sample(r, 100))
+
+	sample := func(seed uint64, k int) BitSet {
+		return bs.Sample(rand.New(rand.NewPCG(seed, seed)), k)
+	}
+	require.Equal(t, sample(42, 3), sample(42, 3), "deterministic with a seeded source")
+
+	// Every element is expected in 3/8 of the samples; allow a loose 10% deviation.
+	const draws = 40000
+	counts := map[int]int{}
+	for range draws {
+		s := bs.Sample(r, 3)
+		require.Equal(t, 3, s.Size())
+		require.True(t, s.Subset(bs))
+		require.NoError(t, s.Validate())
+		s.VisitAll(func(e int) { counts[e]++ })
+	}
+	require.Len(t, counts, bs.Size())
+	for e, c := range counts {
+		require.InDelta(t, draws*3/8, c, float64(draws*3/8/10), "element %d", e)
+	}
+
+	large := FromRange(0, 100_000)
+	s := large.Sample(nil, 1000)
+	require.Equal(t, 1000, s.Size())
+	require.True(t, s.Subset(large))
+}
@@ -0,0 +1,218 @@
+package bitset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Constants of the Roaring portable serialization format, see
+// https://github.com/RoaringBitmap/RoaringFormatSpec.
+const (
+	roaringCookieNoRuns      = 12346 // cookie of bitmaps without run containers
+	roaringCookie            = 12347 // cookie of bitmaps with run containers
+	roaringNoOffsetThreshold = 4     // bitmaps with runs have offsets from this many containers
+	roaringMaxArray          = 4096  // the maximum cardinality of an array container
+	roaringContainerWords    = 1024  // words of a container covering 1<<16 elements
+)
+
+var errRoaringTruncated = errors.New("bitset: truncated roaring data")
+
+// roaringContainer describes a container of a Roaring bitmap.
+type roaringContainer struct {
+	key   int    // the high 16 bits of the elements
+	words BitSet // the words of the container, possibly fewer than 1024
+	card  int
+	runs  int // the number of runs if it is a run container, or 0
+}
+
+// ToRoaringBytes returns the set in the Roaring portable serialization format,
+// which is understood by the Roaring bitmap libraries of many languages.
+// Each container is encoded in the smallest of the array, bitmap and run
+// forms. It returns an error if the set holds elements beyond math.MaxUint32,
+// which the format can't represent.
+func (bs BitSet) ToRoaringBytes() ([]byte, error) {
+	if maxElem := bs.Max(); maxElem >= 0 && uint64(maxElem) > math.MaxUint32 {
+		return nil, fmt.Errorf("bitset: element %d exceeds the roaring maximum of %d", maxElem, uint32(math.MaxUint32))
+	}
+	var containers []roaringContainer
+	hasRuns := false
+	for i := 0; i < len(bs); i += roaringContainerWords {
+		c := roaringContainer{key: i / roaringContainerWords, words: bs[i:min(i+roaringContainerWords, len(bs))]}
+		if c.card = c.words.Size(); c.card == 0 {
+			continue
+		}
+		size := roaringContainerWords * 8 // bitmap
+		if c.card <= roaringMaxArray {
+			size = 2 * c.card
+		}
+		if runs := c.words.NumRanges(); 2+4*runs <= size { // runs win ties, like the reference
+			c.runs = runs
+			hasRuns = true
+		}
+		containers = append(containers, c)
+	}
+
+	n := len(containers)
+	var buf []byte
+	if hasRuns {
+		buf = binary.LittleEndian.AppendUint32(buf, roaringCookie|uint32(n-1)<<16)
+		runFlags := make([]byte, (n+7)/8)
+		for i, c := range containers {
+			if c.runs > 0 {
+				runFlags[i/8] |= 1 << uint(i%8)
+			}
+		}
+		buf = append(buf, runFlags...)
+	} else {
+		buf = binary.LittleEndian.AppendUint32(buf, roaringCookieNoRuns)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(n))
+	}
+	for _, c := range containers {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(c.key))
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(c.card-1))
+	}
+	if !hasRuns || n >= roaringNoOffsetThreshold {
+		offset := len(buf) + 4*n
+		for _, c := range containers {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(offset))
+			switch {
+			case c.runs > 0:
+				offset += 2 + 4*c.runs
+			case c.card <= roaringMaxArray:
+				offset += 2 * c.card
+			default:
+				offset += roaringContainerWords * 8
+			}
+		}
+	}
+	for _, c := range containers {
+		switch {
+		case c.runs > 0:
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(c.runs))
+			for a, b := range c.words.Ranges() {
+				buf = binary.LittleEndian.AppendUint16(buf, uint16(a))
+				buf = binary.LittleEndian.AppendUint16(buf, uint16(b-a))
+			}
+		case c.card <= roaringMaxArray:
+			c.words.VisitAll(func(n int) {
+				buf = binary.LittleEndian.AppendUint16(buf, uint16(n))
+			})
+		default:
+			for i := range roaringContainerWords {
+				buf = binary.LittleEndian.AppendUint64(buf, c.words.Word(i))
+			}
+		}
+	}
+	return buf, nil
+}
+
+// FromRoaringBytes creates a new set from the Roaring portable serialization
+// format, accepting array, bitmap and run containers.
+func FromRoaringBytes(b []byte) (BitSet, error) {
+	if len(b) < 4 {
+		return nil, errRoaringTruncated
+	}
+	cookie := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	var n int
+	var runFlags []byte
+	switch {
+	case cookie == roaringCookieNoRuns:
+		if len(b) < 4 {
+			return nil, errRoaringTruncated
+		}
+		count := binary.LittleEndian.Uint32(b)
+		if count > 1<<16 {
+			return nil, fmt.Errorf("bitset: invalid roaring container count %d", count)
+		}
+		n, b = int(count), b[4:]
+	case cookie&0xffff == roaringCookie:
+		n = int(cookie>>16) + 1
+		if len(b) < (n+7)/8 {
+			return nil, errRoaringTruncated
+		}
+		runFlags, b = b[:(n+7)/8], b[(n+7)/8:]
+	default:
+		return nil, fmt.Errorf("bitset: invalid roaring cookie %d", cookie&0xffff)
+	}
+
+	header := 4 * n
+	if runFlags == nil || n >= roaringNoOffsetThreshold {
+		header += 4 * n // the offsets, which aren't needed for sequential reading
+	}
+	if len(b) < header {
+		return nil, errRoaringTruncated
+	}
+	desc, b := b[:4*n], b[header:]
+
+	var s BitSet
+	prevKey := -1
+	for i := range n {
+		key := int(binary.LittleEndian.Uint16(desc[4*i:]))
+		card := int(binary.LittleEndian.Uint16(desc[4*i+2:])) + 1
+		if key <= prevKey {
+			return nil, fmt.Errorf("bitset: roaring container keys out of order at container %d", i)
+		}
+		prevKey = key
+		base := key << 16
+
+		var got int
+		switch {
+		case runFlags != nil && runFlags[i/8]&(1<<uint(i%8)) != 0:
+			if len(b) < 2 {
+				return nil, errRoaringTruncated
+			}
+			runs := int(binary.LittleEndian.Uint16(b))
+			if len(b) < 2+4*runs {
+				return nil, errRoaringTruncated
+			}
+			for j := range runs {
+				start := int(binary.LittleEndian.Uint16(b[2+4*j:]))
+				length := int(binary.LittleEndian.Uint16(b[4+4*j:])) + 1
+				if start+length > 1<<16 {
+					return nil, fmt.Errorf("bitset: roaring run exceeds container %d", i)
+				}
+				s.AddRange(base+start, base+start+length)
+			}
+			b = b[2+4*runs:]
+			if l := base >> shift; l < len(s) { // no run adds nothing
+				got = s[l:].Size()
+			}
+		case card <= roaringMaxArray:
+			if len(b) < 2*card {
+				return nil, errRoaringTruncated
+			}
+			for j := range card {
+				s.Add(base + int(binary.LittleEndian.Uint16(b[2*j:])))
+			}
+			b = b[2*card:]
+			got = s[base>>shift:].Size()
+		default:
+			if len(b) < roaringContainerWords*8 {
+				return nil, errRoaringTruncated
+			}
+			if l := (key + 1) * roaringContainerWords; l > len(s) {
+				s.resize(l)
+			}
+			for j := range roaringContainerWords {
+				w := binary.LittleEndian.Uint64(b[8*j:])
+				s[key*roaringContainerWords+j] = w
+			}
+			b = b[roaringContainerWords*8:]
+			got = s[base>>shift:].Size()
+		}
+		if got != card {
+			return nil, fmt.Errorf("bitset: roaring container %d holds %d elements, expected %d", i, got, card)
+		}
+	}
+	if len(b) > 0 {
+		return nil, fmt.Errorf("bitset: %d trailing bytes after roaring data", len(b))
+	}
+	s.trim()
+	if s == nil {
+		s = BitSet{}
+	}
+	return s, nil
+}
@@ -0,0 +1,143 @@
+package bitset
+
+import (
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// roaringFixtures are the sets serialized to testdata/roaring by the reference
+// Go implementation github.com/RoaringBitmap/roaring v1.9.4, built with Add
+// and then RunOptimize, which picks the smallest form of every container
+// like ToRoaringBytes.
+func roaringFixtures() map[string]BitSet {
+	bitmap := New()
+	for i := range 10000 {
+		bitmap.Add(i * 3)
+	}
+
+	mixed := New(1, 5<<16, 100<<16|5)
+	mixed.AddRange(1<<16, 3<<16)
+	for i := range 5000 {
+		mixed.Add(7<<16 + i*7)
+	}
+
+	bitmapAndRun := FromRange(1<<20, 1<<20+1000)
+	for i := range 5000 {
+		bitmapAndRun.Add(i * 2)
+	}
+
+	return map[string]BitSet{
+		"empty":          New(),
+		"array":          New(0, 1, 5, 100, 65535),
+		"bitmap":         bitmap,
+		"runs":           Union(FromRange(10, 100000), FromRange(200000, 200010)),
+		"run_tie":        New(0, 1, 2, 1<<16|7, 1<<16|8, 1<<16|9, 1<<16|20),
+		"mixed":          mixed,
+		"bitmap_and_run": bitmapAndRun,
+	}
+}
+
+func TestRoaring(t *testing.T) {
+	for name, bs := range roaringFixtures() {
+		t.Run(name, func(t *testing.T) {
+			golden, err := os.ReadFile(filepath.Join("testdata", "roaring", name+".bin"))
+			require.NoError(t, err)
+
+			got, err := FromRoaringBytes(golden)
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs), got.StringMaxRuns(10))
+			require.NoError(t, got.Validate())
+
+			data, err := bs.ToRoaringBytes()
+			require.NoError(t, err)
+			require.Equal(t, golden, data)
+			got, err = FromRoaringBytes(data)
+			require.NoError(t, err)
+			require.True(t, got.Equal(bs))
+		})
+	}
+}
+
+func TestRoaring_Errors(t *testing.T) {
+	if above := uint64(math.MaxUint32) + 1; bits.UintSize == 64 && !testing.Short() { // the set takes 512 MiB
+		_, err := New(int(above)).ToRoaringBytes()
+		require.EqualError(t, err, "bitset: element 4294967296 exceeds the roaring maximum of 4294967295")
+	}
+
+	valid, err := New(1, 2, 3).ToRoaringBytes()
+	require.NoError(t, err)
+	runs, err := FromRange(0, 1000).ToRoaringBytes()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", nil, "bitset: truncated roaring data"},
+		{"bad cookie", []byte{1, 2, 3, 4}, "bitset: invalid roaring cookie 513"},
+		{"truncated header", valid[:10], "bitset: truncated roaring data"},
+		{"truncated array", valid[:len(valid)-1], "bitset: truncated roaring data"},
+		{"truncated run", runs[:len(runs)-1], "bitset: truncated roaring data"},
+		{"trailing bytes", append(valid[:len(valid):len(valid)], 0), "bitset: 1 trailing bytes after roaring data"},
+		{"huge count", []byte{0x3a, 0x30, 0, 0, 0xff, 0xff, 0xff, 0xff}, "bitset: invalid roaring container count 4294967295"},
+		{
+			"wrong cardinality", []byte{0x3a, 0x30, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 16, 0, 0, 0, 7, 0, 7, 0},
+			"bitset: roaring container 0 holds 1 elements, expected 2",
+		},
+		{
+			"keys out of order", []byte{0x3a, 0x30, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 24, 0, 0, 0, 26, 0, 0, 0, 1, 0, 1, 0},
+			"bitset: roaring container keys out of order at container 1",
+		},
+		{
+			"overlapping runs", []byte{0x3b, 0x30, 0, 0, 1, 0, 0, 3, 0, 2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
+			"bitset: roaring container 0 holds 3 elements, expected 4",
+		},
+		{
+			"duplicate runs", []byte{0x3b, 0x30, 0, 0, 1, 0, 5, 3, 0, 2, 0, 7, 0, 1, 0, 7, 0, 1, 0},
+			"bitset: roaring container 0 holds 2 elements, expected 4",
+		},
+		{
+			"no runs", []byte{0x3b, 0x30, 0, 0, 1, 0, 5, 0, 0, 0, 0},
+			"bitset: roaring container 0 holds 0 elements, expected 1",
+		},
+		{
+			"run beyond container", []byte{0x3b, 0x30, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0xff, 0xff, 1, 0},
+			"bitset: roaring run exceeds container 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, err := FromRoaringBytes(tt.data)
+			require.EqualError(t, err, tt.err)
+			require.Nil(t, bs)
+		})
+	}
+}
+
+func FuzzFromRoaringBytes(f *testing.F) {
+	for name := range roaringFixtures() {
+		golden, err := os.ReadFile(filepath.Join("testdata", "roaring", name+".bin"))
+		require.NoError(f, err)
+		f.Add(golden)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		bs, err := FromRoaringBytes(data)
+		if err != nil {
+			return
+		}
+		require.NoError(t, bs.Validate())
+		out, err := bs.ToRoaringBytes()
+		require.NoError(t, err)
+		again, err := FromRoaringBytes(out)
+		require.NoError(t, err)
+		require.True(t, again.Equal(bs))
+	})
+}
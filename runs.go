@@ -0,0 +1,231 @@
+package bitset
+
+import "slices"
+
+// Run is an inclusive range [Start, End] of consecutive integers.
+type Run struct {
+	Start, End int
+}
+
+// Runs is a set of non-negative integers stored as a sorted list of
+// disjoint, non-adjacent runs. It is much smaller than a BitSet for sets
+// made of a few long runs and converts losslessly to and from a BitSet.
+// Like a BitSet, it holds no element greater than MaxElement.
+// The zero value of Runs is an empty set.
+type Runs []Run
+
+// RunsFromBitSet returns the runs of consecutive elements of bs.
+func RunsFromBitSet(bs BitSet) Runs {
+	rs := make(Runs, 0, bs.NumRanges())
+	for start, end := range bs.Ranges() {
+		rs = append(rs, Run{start, end})
+	}
+	return rs
+}
+
+// ToBitSet returns a BitSet with the same elements as rs.
+// It panics if an element is greater than MaxElement.
+func (rs Runs) ToBitSet() BitSet {
+	if len(rs) == 0 {
+		return BitSet{}
+	}
+	maxElem := rs[len(rs)-1].End
+	mustFit(maxElem)
+	bs := make(BitSet, maxElem>>shift+1)
+	for _, r := range rs {
+		bs.AddRange(r.Start, r.End+1)
+	}
+	return bs
+}
+
+// search returns the index of the first run ending at or after n.
+func (rs Runs) search(n int) int {
+	i, _ := slices.BinarySearchFunc(rs, n, func(r Run, n int) int {
+		if r.End < n {
+			return -1
+		}
+		return 1
+	})
+	return i
+}
+
+// Contains tells if n is in the set.
+func (rs Runs) Contains(n int) bool {
+	i := rs.search(n)
+	return i < len(rs) && rs[i].Start <= n
+}
+
+// Equal tells if the two sets have the same elements.
+func (rs Runs) Equal(other Runs) bool {
+	return slices.Equal(rs, other)
+}
+
+// Size returns the number of elements in the set.
+func (rs Runs) Size() int {
+	size := 0
+	for _, r := range rs {
+		size += r.End - r.Start + 1
+	}
+	return size
+}
+
+// Empty tells if the set is empty.
+func (rs Runs) Empty() bool {
+	return len(rs) == 0
+}
+
+// Visit calls the do function for each element of rs in numerical order
+// until do returns true or all elements are visited.
+func (rs Runs) Visit(do func(n int) bool) (aborted bool) {
+	for _, r := range rs {
+		for n := r.Start; ; n++ {
+			if do(n) {
+				return true
+			}
+			if n == r.End { // n++ would overflow at math.MaxInt
+				break
+			}
+		}
+	}
+	return false
+}
+
+// Add adds the given elements to rs, skipping negative ones.
+// Add panics if an element is greater than MaxElement.
+func (rs *Runs) Add(n ...int) {
+	for _, e := range n {
+		if e >= 0 {
+			mustFit(e)
+			rs.addRun(e, e)
+		}
+	}
+}
+
+// Delete removes the given elements from rs, skipping negative and absent ones.
+func (rs *Runs) Delete(n ...int) {
+	for _, e := range n {
+		if e >= 0 {
+			rs.deleteRun(e, e)
+		}
+	}
+}
+
+// AddRange adds all integers from m to n-1 to rs (no-op if m>=n),
+// merging the runs it overlaps or touches. AddRange panics
+// if n-1 is greater than MaxElement.
+func (rs *Runs) AddRange(m, n int) {
+	m = max(0, m)
+	if m >= n {
+		return
+	}
+	mustFit(n - 1)
+	rs.addRun(m, n-1)
+}
+
+// addRun adds all integers from start to end to rs, 0 ≤ start ≤ end.
+// End may be math.MaxInt, so end+1 is never computed.
+func (rs *Runs) addRun(start, end int) {
+	i := rs.search(start - 1) // first run that may touch start
+	j := i
+	for j < len(*rs) && (*rs)[j].Start-1 <= end {
+		j++
+	}
+	if i < j {
+		start = min(start, (*rs)[i].Start)
+		end = max(end, (*rs)[j-1].End)
+	}
+	*rs = slices.Replace(*rs, i, j, Run{start, end})
+}
+
+// DeleteRange removes all integers from m to n-1 from rs (no-op if m>=n),
+// splitting a run if the range falls inside it.
+func (rs *Runs) DeleteRange(m, n int) {
+	m = max(0, m)
+	if m >= n {
+		return
+	}
+	rs.deleteRun(m, n-1)
+}
+
+// deleteRun removes all integers from start to end from rs, 0 ≤ start ≤ end.
+func (rs *Runs) deleteRun(start, end int) {
+	i := rs.search(start)
+	j := i
+	for j < len(*rs) && (*rs)[j].Start <= end {
+		j++
+	}
+	if i == j {
+		return
+	}
+	var keep [2]Run
+	k := 0
+	if r := (*rs)[i]; r.Start < start {
+		keep[k] = Run{r.Start, start - 1}
+		k++
+	}
+	if r := (*rs)[j-1]; r.End > end {
+		keep[k] = Run{end + 1, r.End}
+		k++
+	}
+	*rs = slices.Replace(*rs, i, j, keep[:k]...)
+}
+
+// Or adds all elements of other to rs by merging the two run lists.
+// Or panics if an element of other is greater than MaxElement.
+func (rs *Runs) Or(other Runs) {
+	if len(other) == 0 {
+		return
+	}
+	mustFit(other[len(other)-1].End)
+	a := *rs
+	res := make(Runs, 0, len(a)+len(other))
+	for i, j := 0, 0; i < len(a) || j < len(other); {
+		var r Run
+		if j == len(other) || i < len(a) && a[i].Start <= other[j].Start {
+			r = a[i]
+			i++
+		} else {
+			r = other[j]
+			j++
+		}
+		if l := len(res) - 1; l >= 0 && r.Start-1 <= res[l].End {
+			res[l].End = max(res[l].End, r.End)
+			continue
+		}
+		res = append(res, r)
+	}
+	*rs = res
+}
+
+// And keeps only the elements of rs that are also in other
+// by intersecting the two run lists.
+func (rs *Runs) And(other Runs) {
+	a := *rs
+	var res Runs
+	for i, j := 0, 0; i < len(a) && j < len(other); {
+		start := max(a[i].Start, other[j].Start)
+		end := min(a[i].End, other[j].End)
+		if start <= end {
+			res = append(res, Run{start, end})
+		}
+		if a[i].End < other[j].End {
+			i++
+		} else {
+			j++
+		}
+	}
+	*rs = res
+}
+
+// String returns a string representation of the set in the same format
+// as BitSet.String.
+func (rs Runs) String() string {
+	buf := []byte{'{'}
+	for i, r := range rs {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = appendRange(buf, r.Start, r.End)
+	}
+	return string(append(buf, '}'))
+}
@@ -0,0 +1,194 @@
+package bitset
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuns_AddRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		runs   Runs
+		m, n   int
+		expect string
+	}{
+		{"empty", nil, 3, 6, "{3..5}"},
+		{"noop", Runs{{1, 2}}, 6, 6, "{1 2}"},
+		{"negative start", nil, -5, 2, "{0 1}"},
+		{"before", Runs{{10, 12}}, 1, 3, "{1 2 10..12}"},
+		{"after", Runs{{10, 12}}, 20, 22, "{10..12 20 21}"},
+		{"touch left", Runs{{10, 12}}, 5, 10, "{5..12}"},
+		{"touch right", Runs{{10, 12}}, 13, 15, "{10..14}"},
+		{"inside", Runs{{10, 20}}, 12, 15, "{10..20}"},
+		{"bridge", Runs{{1, 2}, {5, 6}, {9, 10}, {20, 21}}, 3, 9, "{1..10 20 21}"},
+		{"cover all", Runs{{1, 2}, {5, 6}}, 0, 100, "{0..99}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := tt.runs
+			rs.AddRange(tt.m, tt.n)
+			require.Equal(t, tt.expect, rs.String())
+		})
+	}
+}
+
+func TestRuns_DeleteRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		runs   Runs
+		m, n   int
+		expect string
+	}{
+		{"empty", nil, 3, 6, "{}"},
+		{"miss", Runs{{1, 2}, {10, 12}}, 4, 9, "{1 2 10..12}"},
+		{"split", Runs{{0, 10}}, 3, 6, "{0..2 6..10}"},
+		{"trim left", Runs{{0, 10}}, 0, 4, "{4..10}"},
+		{"trim right", Runs{{0, 10}}, 8, 20, "{0..7}"},
+		{"span", Runs{{0, 3}, {5, 6}, {8, 12}}, 2, 10, "{0 1 10..12}"},
+		{"all", Runs{{0, 3}, {5, 6}}, -1, 100, "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := tt.runs
+			rs.DeleteRange(tt.m, tt.n)
+			require.Equal(t, tt.expect, rs.String())
+		})
+	}
+}
+
+func TestRuns_MaxElement(t *testing.T) {
+	var rs Runs
+	rs.Add(MaxElement, 5)
+	require.Equal(t, Runs{{5, 5}, {MaxElement, MaxElement}}, rs)
+	rs.AddRange(MaxElement-3, MaxElement)
+	require.Equal(t, Runs{{5, 5}, {MaxElement - 3, MaxElement}}, rs)
+	require.Equal(t, 5, rs.Size())
+	rs.Delete(MaxElement)
+	require.Equal(t, Runs{{5, 5}, {MaxElement - 3, MaxElement - 1}}, rs)
+
+	if MaxElement < math.MaxInt {
+		msg := fmt.Sprintf("bitset: element %d exceeds the maximum of %d", math.MaxInt, MaxElement)
+		require.PanicsWithError(t, msg, func() { rs.Add(math.MaxInt) })
+		require.PanicsWithError(t,
+			fmt.Sprintf("bitset: element %d exceeds the maximum of %d", math.MaxInt-1, MaxElement),
+			func() { rs.AddRange(0, math.MaxInt) })
+		require.PanicsWithError(t, msg, func() { rs.Or(Runs{{math.MaxInt, math.MaxInt}}) })
+		require.PanicsWithError(t, msg, func() { Runs{{math.MaxInt, math.MaxInt}}.ToBitSet() })
+		require.Equal(t, Runs{{5, 5}, {MaxElement - 3, MaxElement - 1}}, rs)
+	}
+
+	// Visit stops at the end of a run ending at math.MaxInt.
+	var visited []int
+	Runs{{math.MaxInt - 1, math.MaxInt}}.Visit(func(n int) bool {
+		visited = append(visited, n)
+		return len(visited) > 3
+	})
+	require.Equal(t, []int{math.MaxInt - 1, math.MaxInt}, visited)
+	require.Equal(t, 2, Runs{{math.MaxInt - 1, math.MaxInt}}.Size())
+
+	maxElement = 1000
+	t.Cleanup(func() { maxElement = MaxElement })
+	rs = nil
+	rs.Add(1000)
+	rs.AddRange(990, 1001)
+	require.Equal(t, "{990..1000}", rs.String())
+	require.PanicsWithError(t, "bitset: element 1001 exceeds the maximum of 1000",
+		func() { rs.Add(1001) })
+	require.PanicsWithError(t, "bitset: element 1001 exceeds the maximum of 1000",
+		func() { rs.AddRange(5, 1002) })
+	require.PanicsWithError(t, "bitset: element 1001 exceeds the maximum of 1000",
+		func() { rs.Or(Runs{{1001, 1001}}) })
+	require.PanicsWithError(t, "bitset: element 1001 exceeds the maximum of 1000",
+		func() { Runs{{3, 1001}}.ToBitSet() })
+	require.Equal(t, New(990, 1000), Runs{{990, 990}, {1000, 1000}}.ToBitSet())
+	require.Equal(t, "{990..1000}", rs.String())
+}
+
+func TestRuns_Ops(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Runs
+		or   string
+		and  string
+	}{
+		{"both empty", nil, nil, "{}", "{}"},
+		{"a empty", nil, Runs{{1, 5}}, "{1..5}", "{}"},
+		{"adjacent", Runs{{1, 5}}, Runs{{6, 9}}, "{1..9}", "{}"},
+		{"overlap", Runs{{1, 5}, {10, 20}}, Runs{{4, 12}}, "{1..20}", "{4 5 10..12}"},
+		{"interleaved", Runs{{0, 1}, {10, 11}}, Runs{{5, 6}, {15, 16}}, "{0 1 5 6 10 11 15 16}", "{}"},
+		{"contained", Runs{{0, 100}}, Runs{{3, 4}, {50, 60}}, "{0..100}", "{3 4 50..60}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := append(Runs(nil), tt.a...)
+			rs.Or(tt.b)
+			require.Equal(t, tt.or, rs.String())
+
+			rs = append(Runs(nil), tt.a...)
+			rs.And(tt.b)
+			require.Equal(t, tt.and, rs.String())
+		})
+	}
+}
+
+func TestRuns_Conversion(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		var bs BitSet
+		var rs Runs
+		for range 20 {
+			m := r.IntN(1000)
+			n := m + r.IntN(100)
+			if r.IntN(3) == 0 {
+				bs.DeleteRange(m, n)
+				rs.DeleteRange(m, n)
+			} else {
+				bs.AddRange(m, n)
+				rs.AddRange(m, n)
+			}
+			e := r.IntN(1100)
+			bs.Add(e)
+			rs.Add(e)
+			e = r.IntN(1100)
+			bs.Delete(e)
+			rs.Delete(e)
+		}
+
+		require.Equal(t, bs.String(), rs.String())
+		require.Equal(t, bs.Size(), rs.Size())
+		require.True(t, RunsFromBitSet(bs).Equal(rs))
+		require.True(t, rs.ToBitSet().Equal(bs))
+		require.True(t, RunsFromBitSet(rs.ToBitSet()).Equal(rs))
+		for n := range 1100 {
+			require.Equal(t, bs.Contains(n), rs.Contains(n), n)
+		}
+
+		other := New(r.IntN(1000), r.IntN(1000))
+		other.AddRange(r.IntN(1000), r.IntN(1000))
+		or, and := rs, append(Runs(nil), rs...)
+		or.Or(RunsFromBitSet(other))
+		and.And(RunsFromBitSet(other))
+		require.Equal(t, Or(bs, other).String(), or.String())
+		require.Equal(t, And(bs, other).String(), and.String())
+	}
+}
+
+func TestRuns_Visit(t *testing.T) {
+	rs := Runs{{1, 3}, {7, 8}}
+	var visited []int
+	aborted := rs.Visit(func(n int) bool {
+		visited = append(visited, n)
+		return n == 7
+	})
+	require.True(t, aborted)
+	require.Equal(t, []int{1, 2, 3, 7}, visited)
+	require.True(t, Runs(nil).Empty())
+	require.Equal(t, "{}", Runs(nil).ToBitSet().String())
+}
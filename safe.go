@@ -0,0 +1,89 @@
+package bitset
+
+import "sync"
+
+// Safe is a set that is safe for concurrent use by multiple goroutines.
+// It wraps a BitSet with a sync.RWMutex. The zero value of Safe is an empty set
+// ready to use. A Safe must not be copied after first use.
+type Safe struct {
+	mu sync.RWMutex
+	bs BitSet
+}
+
+// NewSafe creates a new concurrency-safe set with the given non-negative elements.
+func NewSafe(n ...int) *Safe {
+	return &Safe{bs: New(n...)}
+}
+
+// Add adds the given elements to the set, skipping negative ones.
+func (s *Safe) Add(n ...int) {
+	s.mu.Lock()
+	s.bs.Add(n...)
+	s.mu.Unlock()
+}
+
+// Delete removes the given elements from the set, skipping negative
+// and absent ones.
+func (s *Safe) Delete(n ...int) {
+	s.mu.Lock()
+	s.bs.Delete(n...)
+	s.mu.Unlock()
+}
+
+// Contains tells if n is in the set.
+func (s *Safe) Contains(n int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bs.Contains(n)
+}
+
+// Size returns the number of elements in the set.
+func (s *Safe) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bs.Size()
+}
+
+// Visit calls the do function for each element of a snapshot of the set
+// in numerical order, with the same abort semantics as BitSet.Visit.
+// The lock is not held while do runs, so do may modify the set,
+// and such changes are not observed by the running Visit.
+func (s *Safe) Visit(do func(n int) bool) (aborted bool) {
+	return s.Snapshot().Visit(do)
+}
+
+// Or adds all elements of other to the set.
+func (s *Safe) Or(other BitSet) {
+	s.mu.Lock()
+	s.bs.Or(other)
+	s.mu.Unlock()
+}
+
+// And keeps only the elements of the set that are also in other.
+func (s *Safe) And(other BitSet) {
+	s.mu.Lock()
+	s.bs.And(other)
+	s.mu.Unlock()
+}
+
+// AndNot removes all elements of other from the set.
+func (s *Safe) AndNot(other BitSet) {
+	s.mu.Lock()
+	s.bs.AndNot(other)
+	s.mu.Unlock()
+}
+
+// Snapshot returns a copy of the set.
+func (s *Safe) Snapshot() BitSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bs.Copy()
+}
+
+// String returns a string representation of the set in the same format
+// as BitSet.String.
+func (s *Safe) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bs.String()
+}
@@ -0,0 +1,77 @@
+package bitset
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafe(t *testing.T) {
+	s := NewSafe(1, 2)
+	s.Add(64, 300)
+	s.Delete(2)
+	require.True(t, s.Contains(64))
+	require.False(t, s.Contains(2))
+	require.Equal(t, 3, s.Size())
+	require.Equal(t, "{1 64 300}", s.String())
+
+	s.Or(New(5))
+	s.AndNot(New(1))
+	require.Equal(t, "{5 64 300}", s.String())
+	s.And(New(5, 64))
+	require.Equal(t, "{5 64}", s.String())
+
+	snap := s.Snapshot()
+	s.Add(1000)
+	require.Equal(t, "{5 64}", snap.String())
+
+	var zero Safe
+	zero.Add(3)
+	require.Equal(t, "{3}", zero.String())
+}
+
+func TestSafe_Visit(t *testing.T) {
+	s := NewSafe(1, 2, 100)
+	var visited []int
+	aborted := s.Visit(func(n int) bool {
+		visited = append(visited, n)
+		s.Add(n + 1000) // does not deadlock and is not observed
+		return false
+	})
+	require.False(t, aborted)
+	require.Equal(t, []int{1, 2, 100}, visited)
+	require.Equal(t, 6, s.Size())
+
+	count := 0
+	require.True(t, s.Visit(func(n int) bool {
+		count++
+		return true
+	}))
+	require.Equal(t, 1, count)
+}
+
+func TestSafe_Concurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+	var s Safe
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range perGoroutine {
+				s.Add(g*perGoroutine + i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := range perGoroutine {
+				s.Contains(i)
+				_ = s.Size()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, goroutines*perGoroutine, s.Size())
+	require.True(t, s.Snapshot().Equal(FromRange(0, goroutines*perGoroutine)))
+}
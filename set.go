@@ -0,0 +1,824 @@
+package bitset
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"iter"
+	"math/big"
+	"math/rand/v2"
+)
+
+// Set is a set of non-negative integers like BitSet, but its words are
+// hidden in a struct, so that callers can't break the invariants of the
+// representation, and the type can gain cached fields without breaking
+// its users. Set has the same methods as BitSet, taking and returning Set
+// in place of BitSet; ToSet and AsBitSet convert between the two.
+// Like a BitSet, a copied Set shares its storage with the original;
+// use Copy to get an independent set. The zero value of Set is an empty set.
+type Set struct {
+	words []uint64
+}
+
+// NewSet creates a new set with the given non-negative elements.
+// It panics if an element is greater than MaxElement.
+func NewSet(n ...int) Set {
+	return Set{New(n...)}
+}
+
+// ToSet creates a new set with the elements of bs.
+// Later changes to bs don't affect the returned set.
+func ToSet(bs BitSet) Set {
+	return Set{bs[:bs.trimmedLen()].Copy()}
+}
+
+// AsBitSet returns the elements of s as a new BitSet.
+// Later changes to the returned set don't affect s.
+func AsBitSet(s Set) BitSet {
+	return BitSet(s.words).Copy()
+}
+
+// bits returns the words of s as a *BitSet, so that the pointer methods
+// of BitSet update s in place.
+func (s *Set) bits() *BitSet {
+	return (*BitSet)(&s.words)
+}
+
+// Reset resets the set without reallocation.
+func (s *Set) Reset() {
+	s.bits().Reset()
+}
+
+// ResetFree resets the set and drops its storage, so that it can be garbage
+// collected.
+func (s *Set) ResetFree() {
+	s.bits().ResetFree()
+}
+
+// Contains tells if n is in the set.
+func (s Set) Contains(n int) bool {
+	return BitSet(s.words).Contains(n)
+}
+
+// ContainsAll tells if all of n are in the set.
+func (s Set) ContainsAll(n ...int) bool {
+	return BitSet(s.words).ContainsAll(n...)
+}
+
+// ContainsAny tells if at least one of n is in the set.
+func (s Set) ContainsAny(n ...int) bool {
+	return BitSet(s.words).ContainsAny(n...)
+}
+
+// ContainsRange tells if all integers from m to n-1 are in the set.
+func (s Set) ContainsRange(m, n int) bool {
+	return BitSet(s.words).ContainsRange(m, n)
+}
+
+// Equal tells if s and other are equal.
+func (s Set) Equal(other Set) bool {
+	return BitSet(s.words).Equal(other.words)
+}
+
+// Cmp compares s and other as if they were big integers with bit n set for
+// every element n, and returns -1 if s < other, 0 if s == other and +1
+// if s > other.
+func (s Set) Cmp(other Set) int {
+	return BitSet(s.words).Cmp(other.words)
+}
+
+// Subset tells if s is a subset of other.
+func (s Set) Subset(other Set) bool {
+	return BitSet(s.words).Subset(other.words)
+}
+
+// ProperSubset tells if s is a subset of other and other has at least one
+// element that is not in s.
+func (s Set) ProperSubset(other Set) bool {
+	return BitSet(s.words).ProperSubset(other.words)
+}
+
+// Superset tells if s is a superset of other.
+func (s Set) Superset(other Set) bool {
+	return BitSet(s.words).Superset(other.words)
+}
+
+// ProperSuperset tells if s is a superset of other and s has at least one
+// element that is not in other.
+func (s Set) ProperSuperset(other Set) bool {
+	return BitSet(s.words).ProperSuperset(other.words)
+}
+
+// Intersects tells if s and other have at least one element in common.
+func (s Set) Intersects(other Set) bool {
+	return BitSet(s.words).Intersects(other.words)
+}
+
+// Disjoint tells if s and other have no elements in common.
+func (s Set) Disjoint(other Set) bool {
+	return BitSet(s.words).Disjoint(other.words)
+}
+
+// Min returns the minimum element of the bitset.
+func (s Set) Min() int {
+	return BitSet(s.words).Min()
+}
+
+// Max returns the maximum element of the bitset.
+func (s Set) Max() int {
+	return BitSet(s.words).Max()
+}
+
+// Size returns the number of elements in the set.
+func (s Set) Size() int {
+	return BitSet(s.words).Size()
+}
+
+// CountRange returns the number of elements e, m ≤ e < n, in the set.
+func (s Set) CountRange(m, n int) int {
+	return BitSet(s.words).CountRange(m, n)
+}
+
+// Rank returns the number of elements e, e < n, in the set.
+func (s Set) Rank(n int) int {
+	return BitSet(s.words).Rank(n)
+}
+
+// Select returns the k-th smallest element of the set, counting from 0, or
+// -1 if there is no such element.
+func (s Set) Select(k int) int {
+	return BitSet(s.words).Select(k)
+}
+
+// Quantile returns the element of rank floor(q*(Size()-1)), counting from
+// 0, so that Quantile(0) is Min() and Quantile(1) is Max().
+func (s Set) Quantile(q float64) int {
+	return BitSet(s.words).Quantile(q)
+}
+
+// Median returns the element of rank Size()/2, counting from 0, which is
+// the upper of the two middle elements if the size is even, or -1 if s is
+// empty.
+func (s Set) Median() int {
+	return BitSet(s.words).Median()
+}
+
+// Empty tells if the set is empty.
+func (s Set) Empty() bool {
+	return BitSet(s.words).Empty()
+}
+
+// Next returns the next element n, n > m, in the set, or -1 if there is no
+// such element.
+func (s Set) Next(m int) int {
+	return BitSet(s.words).Next(m)
+}
+
+// NextMany appends the elements n, n > m, of the set to buf in ascending
+// order until buf is full, that is, up to cap(buf)-len(buf) elements, and
+// returns the extended buffer.
+func (s Set) NextMany(m int, buf []int) []int {
+	return BitSet(s.words).NextMany(m, buf)
+}
+
+// Ceil returns the smallest element n, n ≥ m, in the set, or -1 if there is
+// no such element.
+func (s Set) Ceil(m int) int {
+	return BitSet(s.words).Ceil(m)
+}
+
+// Floor returns the greatest element n, n ≤ m, in the set, or -1 if there
+// is no such element.
+func (s Set) Floor(m int) int {
+	return BitSet(s.words).Floor(m)
+}
+
+// Prev returns the previous element n, n < m, in the set, or -1 if there is
+// no such element.
+func (s Set) Prev(m int) int {
+	return BitSet(s.words).Prev(m)
+}
+
+// NextClear returns the next integer n, n > m, not in the set,
+// or -1 if m is math.MaxInt.
+func (s Set) NextClear(m int) int {
+	return BitSet(s.words).NextClear(m)
+}
+
+// PrevClear returns the previous integer n, 0 ≤ n < m, not in the set, or
+// -1 if there is no such integer.
+func (s Set) PrevClear(m int) int {
+	return BitSet(s.words).PrevClear(m)
+}
+
+// Visit calls the do function for each element of s in numerical order.
+func (s Set) Visit(do func(n int) bool) (aborted bool) {
+	return BitSet(s.words).Visit(do)
+}
+
+// Every tells if pred returns true for every element of s, stopping at the
+// first element for which it returns false.
+func (s Set) Every(pred func(n int) bool) bool {
+	return BitSet(s.words).Every(pred)
+}
+
+// Any tells if pred returns true for some element of s, stopping at the
+// first such element.
+func (s Set) Any(pred func(n int) bool) bool {
+	return BitSet(s.words).Any(pred)
+}
+
+// None tells if pred returns false for every element of s, stopping at the
+// first element for which it returns true.
+func (s Set) None(pred func(n int) bool) bool {
+	return BitSet(s.words).None(pred)
+}
+
+// VisitGrowing calls the do function for each element of *s in numerical
+// order with the same abort semantics as Visit, but it is safe for do to
+// change the set arbitrarily: the next element is looked up in the current
+// contents of *s after each call.
+func (s *Set) VisitGrowing(do func(n int) bool) (aborted bool) {
+	return s.bits().VisitGrowing(do)
+}
+
+// VisitRange calls the do function for each element e, m ≤ e < n, of s in
+// numerical order.
+func (s Set) VisitRange(m, n int, do func(n int) bool) (aborted bool) {
+	return BitSet(s.words).VisitRange(m, n, do)
+}
+
+// VisitDescending calls the do function for each element of s in descending
+// numerical order.
+func (s Set) VisitDescending(do func(n int) bool) (aborted bool) {
+	return BitSet(s.words).VisitDescending(do)
+}
+
+// VisitAll calls do function for each element of s in numerical order.
+func (s Set) VisitAll(do func(n int)) {
+	BitSet(s.words).VisitAll(do)
+}
+
+// All returns an iterator over the elements of the set in ascending order.
+func (s Set) All() iter.Seq[int] {
+	return BitSet(s.words).All()
+}
+
+// Backward returns an iterator over the elements of the set in descending
+// order.
+func (s Set) Backward() iter.Seq[int] {
+	return BitSet(s.words).Backward()
+}
+
+// Ranges returns an iterator over the maximal runs of consecutive elements
+// of the set in ascending order.
+func (s Set) Ranges() iter.Seq2[int, int] {
+	return BitSet(s.words).Ranges()
+}
+
+// Gaps returns an iterator over the maximal runs of integers absent from
+// the set strictly between Min() and Max(), in ascending order.
+func (s Set) Gaps() iter.Seq2[int, int] {
+	return BitSet(s.words).Gaps()
+}
+
+// LongestRun returns the smallest start and the length of the longest run
+// of consecutive elements of the set, or (-1, 0) if the set is empty.
+func (s Set) LongestRun() (start, length int) {
+	return BitSet(s.words).LongestRun()
+}
+
+// LongestGap returns the smallest start and the length of the longest run
+// of integers absent from the set strictly between Min() and Max(), or
+// (-1, 0) if there is no such integer.
+func (s Set) LongestGap() (start, length int) {
+	return BitSet(s.words).LongestGap()
+}
+
+// NumRanges returns the number of maximal runs of consecutive elements in
+// the set.
+func (s Set) NumRanges() int {
+	return BitSet(s.words).NumRanges()
+}
+
+// IsContiguous tells if the set is empty or consists of a single run of
+// consecutive elements.
+func (s Set) IsContiguous() bool {
+	return BitSet(s.words).IsContiguous()
+}
+
+// ToSlice returns the elements of the set in ascending order.
+func (s Set) ToSlice() []int {
+	return BitSet(s.words).ToSlice()
+}
+
+// AppendTo appends the elements of the set in ascending order to buf and
+// returns the extended buffer.
+func (s Set) AppendTo(buf []int) []int {
+	return BitSet(s.words).AppendTo(buf)
+}
+
+// MaxN appends the k largest elements of the set in descending order to buf
+// and returns the extended buffer; all elements if k ≥ Size().
+func (s Set) MaxN(k int, buf []int) []int {
+	return BitSet(s.words).MaxN(k, buf)
+}
+
+// MinN appends the k smallest elements of the set in ascending order to buf
+// and returns the extended buffer; all elements if k ≥ Size().
+func (s Set) MinN(k int, buf []int) []int {
+	return BitSet(s.words).MinN(k, buf)
+}
+
+// ToBools returns a mask of length n where the element at index i is true
+// if i is in the set.
+func (s Set) ToBools(n int) []bool {
+	return BitSet(s.words).ToBools(n)
+}
+
+// Words returns a copy of the words of the set, see FromWords.
+func (s Set) Words() []uint64 {
+	return BitSet(s.words).Words()
+}
+
+// Word returns the i-th word of the set, holding the elements from 64*i to
+// 64*i + 63, or 0 if i is out of range.
+func (s Set) Word(i int) uint64 {
+	return BitSet(s.words).Word(i)
+}
+
+// Grow ensures that s can hold the elements up to n without reallocation.
+func (s *Set) Grow(n int) {
+	s.bits().Grow(n)
+}
+
+// Clip reallocates s to release the capacity beyond its length, if there is
+// any.
+func (s *Set) Clip() {
+	s.bits().Clip()
+}
+
+// Cap returns the number of elements s can hold without reallocation.
+func (s Set) Cap() int {
+	return BitSet(s.words).Cap()
+}
+
+// ByteSize returns the memory footprint of s in bytes: its capacity in
+// words plus the slice header.
+func (s Set) ByteSize() int {
+	return BitSet(s.words).ByteSize()
+}
+
+// Stats returns the memory usage and the layout statistics of s, computed
+// in one pass over its words.
+func (s Set) Stats() Stats {
+	return BitSet(s.words).Stats()
+}
+
+// Validate checks the invariants of s and returns an error describing the
+// first violation: the last word must not be zero.
+func (s Set) Validate() error {
+	return BitSet(s.words).Validate()
+}
+
+// Set replaces the contents of *s with other, reusing the capacity of *s if
+// possible.
+func (s *Set) Set(other Set) {
+	s.bits().Set(other.words)
+}
+
+// Copy creates a new set that is a copy of s.
+func (s Set) Copy() Set {
+	return Set{BitSet(s.words).Copy()}
+}
+
+// CopyTo replaces the contents of *dst with s, reusing the capacity of *dst
+// if possible.
+func (s Set) CopyTo(dst *Set) {
+	BitSet(s.words).CopyTo(dst.bits())
+}
+
+// Add adds the given elements to s, skipping negative ones.
+func (s *Set) Add(n ...int) {
+	s.bits().Add(n...)
+}
+
+// Delete removes the given elements from s, skipping negative and absent
+// ones.
+func (s *Set) Delete(n ...int) {
+	s.bits().Delete(n...)
+}
+
+// AddSlice adds the elements of ns to s, skipping negative ones.
+func (s *Set) AddSlice(ns []int) {
+	s.bits().AddSlice(ns)
+}
+
+// DeleteSlice removes the elements of ns from s.
+func (s *Set) DeleteSlice(ns []int) {
+	s.bits().DeleteSlice(ns)
+}
+
+// DeleteNoTrim removes n from s like Delete, but leaves trailing zero words
+// in place, so that a batch of deletes can be followed by a single Trim.
+func (s *Set) DeleteNoTrim(n int) {
+	s.bits().DeleteNoTrim(n)
+}
+
+// Trim removes the trailing zero words of s left by DeleteNoTrim and
+// DeleteRangeNoTrim.
+func (s *Set) Trim() {
+	s.bits().Trim()
+}
+
+// SetBit adds n to s if v is true and removes it otherwise (no-op if n < 0).
+func (s *Set) SetBit(n int, v bool) {
+	s.bits().SetBit(n, v)
+}
+
+// TestAndSet adds n to s and tells if it was already present
+// (no-op if n < 0).
+func (s *Set) TestAndSet(n int) bool {
+	return s.bits().TestAndSet(n)
+}
+
+// TestAndClear removes n from s and tells if it was present (no-op if n < 0).
+func (s *Set) TestAndClear(n int) bool {
+	return s.bits().TestAndClear(n)
+}
+
+// PopMin removes the minimum element from s and returns it.
+func (s *Set) PopMin() int {
+	return s.bits().PopMin()
+}
+
+// PopMax removes the maximum element from s and returns it.
+func (s *Set) PopMax() int {
+	return s.bits().PopMax()
+}
+
+// RemoveIf removes every element n of s for which pred(n) returns true.
+func (s *Set) RemoveIf(pred func(n int) bool) {
+	s.bits().RemoveIf(pred)
+}
+
+// RetainIf removes every element n of s for which pred(n) returns false.
+func (s *Set) RetainIf(pred func(n int) bool) {
+	s.bits().RetainIf(pred)
+}
+
+// Partition creates two new sets: yes consists of the elements n of s for
+// which pred(n) returns true and no of the rest.
+func (s Set) Partition(pred func(n int) bool) (yes, no Set) {
+	a, b := BitSet(s.words).Partition(pred)
+	return Set{a}, Set{b}
+}
+
+// Map creates a new set that consists of f(n) for every element n of s,
+// dropping the negative results.
+func (s Set) Map(f func(n int) int) Set {
+	return Set{BitSet(s.words).Map(f)}
+}
+
+// Compact creates a new set that consists of index.Rank(n) for every
+// element n of s that is in index.
+func (s Set) Compact(index Set) Set {
+	return Set{BitSet(s.words).Compact(index.words)}
+}
+
+// AddRange adds all integers from m to n-1 to s (no-op if m>=n).
+func (s *Set) AddRange(m, n int) {
+	s.bits().AddRange(m, n)
+}
+
+// AddCapped adds the given elements to s like Add, but returns an error and
+// leaves s unchanged if any element exceeds maxAllowed or MaxElement.
+func (s *Set) AddCapped(maxAllowed int, n ...int) error {
+	return s.bits().AddCapped(maxAllowed, n...)
+}
+
+// AddRangeCapped adds all integers from m to n-1 to s like AddRange, but
+// returns an error and leaves s unchanged if n-1 exceeds maxAllowed or
+// MaxElement.
+func (s *Set) AddRangeCapped(maxAllowed, m, n int) error {
+	return s.bits().AddRangeCapped(maxAllowed, m, n)
+}
+
+// DeleteRange removes all integers from m to n-1 (no-op if m>=n).
+func (s *Set) DeleteRange(m, n int) {
+	s.bits().DeleteRange(m, n)
+}
+
+// DeleteRangeNoTrim removes all integers from m to n-1 like DeleteRange,
+// but leaves trailing zero words in place.
+func (s *Set) DeleteRangeNoTrim(m, n int) {
+	s.bits().DeleteRangeNoTrim(m, n)
+}
+
+// Extract creates a new set that consists of the elements of s from m to n-1.
+func (s Set) Extract(m, n int) Set {
+	return Set{BitSet(s.words).Extract(m, n)}
+}
+
+// Window creates a new set that consists of the elements of s from m to n-1
+// decreased by m, so that m becomes 0.
+func (s Set) Window(m, n int) Set {
+	return Set{BitSet(s.words).Window(m, n)}
+}
+
+// Split creates two new sets: lo consists of the elements of s less than n
+// and hi of the rest.
+func (s Set) Split(n int) (lo, hi Set) {
+	a, b := BitSet(s.words).Split(n)
+	return Set{a}, Set{b}
+}
+
+// TruncateAt removes all elements of s that are greater than or equal to n.
+func (s *Set) TruncateAt(n int) {
+	s.bits().TruncateAt(n)
+}
+
+// KeepRange removes all elements of s outside of m to n-1.
+func (s *Set) KeepRange(m, n int) {
+	s.bits().KeepRange(m, n)
+}
+
+// Flip adds n to s if it is absent and removes it otherwise (no-op if n < 0).
+func (s *Set) Flip(n int) {
+	s.bits().Flip(n)
+}
+
+// FlipRange flips all integers from m to n-1 in s (no-op if m>=n).
+func (s *Set) FlipRange(m, n int) {
+	s.bits().FlipRange(m, n)
+}
+
+// Complement creates a new set that consists of all integers from 0 to n-1
+// that are not in s.
+func (s Set) Complement(n int) Set {
+	return Set{BitSet(s.words).Complement(n)}
+}
+
+// ComplementRange replaces the elements of s within m to n-1 by the
+// integers of that range that were not in s (no-op if m>=n).
+func (s *Set) ComplementRange(m, n int) {
+	s.bits().ComplementRange(m, n)
+}
+
+// ShiftLeft adds k to every element of s (no-op if k ≤ 0).
+func (s *Set) ShiftLeft(k int) {
+	s.bits().ShiftLeft(k)
+}
+
+// ShiftRight subtracts k from every element of s, dropping the elements
+// that become negative (no-op if k ≤ 0).
+func (s *Set) ShiftRight(k int) {
+	s.bits().ShiftRight(k)
+}
+
+// And keeps only bits set in both *s and other.
+func (s *Set) And(other Set) {
+	s.bits().And(other.words)
+}
+
+// Or sets bits that are set in either *s or other.
+func (s *Set) Or(other Set) {
+	s.bits().Or(other.words)
+}
+
+// Xor toggles bits that are set in either *s or other but not both.
+func (s *Set) Xor(other Set) {
+	s.bits().Xor(other.words)
+}
+
+// AndNot removes bits that are set in other from *s.
+func (s *Set) AndNot(other Set) {
+	s.bits().AndNot(other.words)
+}
+
+// OrChanged adds the elements of other to *s like Or and tells if at least
+// one of them was not in *s.
+func (s *Set) OrChanged(other Set) bool {
+	return s.bits().OrChanged(other.words)
+}
+
+// AndChanged removes the elements of *s that are not in other like And
+// and tells if at least one element was removed.
+func (s *Set) AndChanged(other Set) bool {
+	return s.bits().AndChanged(other.words)
+}
+
+// AndNotChanged removes the elements of other from *s like AndNot
+// and tells if at least one element was removed.
+func (s *Set) AndNotChanged(other Set) bool {
+	return s.bits().AndNotChanged(other.words)
+}
+
+// OrRange adds the elements e, m ≤ e < n, of other to *s (no-op if m>=n).
+func (s *Set) OrRange(other Set, m, n int) {
+	s.bits().OrRange(other.words, m, n)
+}
+
+// AndRange removes the elements e, m ≤ e < n, of *s that are not in other
+// (no-op if m>=n).
+func (s *Set) AndRange(other Set, m, n int) {
+	s.bits().AndRange(other.words, m, n)
+}
+
+// AndNotRange removes the elements e, m ≤ e < n, of other from *s (no-op if
+// m>=n).
+func (s *Set) AndNotRange(other Set, m, n int) {
+	s.bits().AndNotRange(other.words, m, n)
+}
+
+// bitSets returns the words of the given sets as BitSet values.
+func bitSets(sets []Set) []BitSet {
+	bs := make([]BitSet, len(sets))
+	for i, o := range sets {
+		bs[i] = o.words
+	}
+	return bs
+}
+
+// OrAll adds the elements of all others to s.
+// s is resized and trimmed once.
+func (s *Set) OrAll(others ...Set) {
+	s.bits().OrAll(bitSets(others)...)
+}
+
+// AndAll removes the elements of s that are not in every one of others.
+// s is trimmed once at the end.
+func (s *Set) AndAll(others ...Set) {
+	s.bits().AndAll(bitSets(others)...)
+}
+
+// String returns a string representation of the set.
+func (s Set) String() string {
+	return BitSet(s.words).String()
+}
+
+// AppendString appends the String representation of the set to buf and
+// returns the extended buffer.
+func (s Set) AppendString(buf []byte) []byte {
+	return BitSet(s.words).AppendString(buf)
+}
+
+// StringMaxRuns returns a string representation of the set like String, but
+// lists at most maxRuns runs of consecutive elements followed by the number
+// of elements left out.
+func (s Set) StringMaxRuns(maxRuns int) string {
+	return BitSet(s.words).StringMaxRuns(maxRuns)
+}
+
+// Summary returns a one-line description of s for logs, computed in one
+// pass over the words.
+func (s Set) Summary() string {
+	return BitSet(s.words).Summary()
+}
+
+// Format implements the fmt.Formatter interface.
+func (s Set) Format(f fmt.State, verb rune) {
+	BitSet(s.words).Format(f, verb)
+}
+
+// GoString implements the fmt.GoStringer interface and returns Go source
+// that rebuilds the set, e.g. bitset.ToSet(bitset.New(1, 2, 128)).
+func (s Set) GoString() string {
+	return "bitset.ToSet(" + BitSet(s.words).GoString() + ")"
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (s Set) MarshalBinary() ([]byte, error) {
+	return BitSet(s.words).MarshalBinary()
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (s *Set) UnmarshalBinary(data []byte) error {
+	return s.bits().UnmarshalBinary(data)
+}
+
+// GobEncode implements the gob.GobEncoder interface with the encoding
+// produced by MarshalBinary.
+func (s Set) GobEncode() ([]byte, error) {
+	return BitSet(s.words).GobEncode()
+}
+
+// GobDecode implements the gob.GobDecoder interface.
+func (s *Set) GobDecode(data []byte) error {
+	return s.bits().GobDecode(data)
+}
+
+// WriteTo implements the io.WriterTo interface.
+func (s Set) WriteTo(w io.Writer) (int64, error) {
+	return BitSet(s.words).WriteTo(w)
+}
+
+// ReadFrom implements the io.ReaderFrom interface.
+func (s *Set) ReadFrom(r io.Reader) (int64, error) {
+	return s.bits().ReadFrom(r)
+}
+
+// Bytes returns the words of the set in little-endian order with trailing
+// zero bytes removed, so that bit j of byte i corresponds to element 8*i +
+// j.
+func (s Set) Bytes() []byte {
+	return BitSet(s.words).Bytes()
+}
+
+// BitString returns the bits of the set as '0' and '1' characters, least
+// significant first, so that the character at index i corresponds to
+// element i.
+func (s Set) BitString() string {
+	return BitSet(s.words).BitString()
+}
+
+// BitStringMSB returns the bits of the set as '0' and '1' characters in
+// bytes ordered most significant bit first: the string consists of groups
+// of eight characters, where group i covers the elements 8*i+7 down to 8*i.
+func (s Set) BitStringMSB() string {
+	return BitSet(s.words).BitStringMSB()
+}
+
+// ToBigInt returns a non-negative big.Int with bit n set for every element n.
+func (s Set) ToBigInt() *big.Int {
+	return BitSet(s.words).ToBigInt()
+}
+
+// MarshalCompressed returns a compact encoding for sparse sets: the number
+// of elements followed by the first element and the gaps between
+// consecutive elements, all as unsigned varints.
+func (s Set) MarshalCompressed() []byte {
+	return BitSet(s.words).MarshalCompressed()
+}
+
+// HexString returns the layout produced by Bytes as lowercase hexadecimal,
+// so that byte i of the decoded string covers the elements 8*i to 8*i+7,
+// least significant bit first.
+func (s Set) HexString() string {
+	return BitSet(s.words).HexString()
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s Set) MarshalJSON() ([]byte, error) {
+	return BitSet(s.words).MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	return s.bits().UnmarshalJSON(data)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (s Set) MarshalText() ([]byte, error) {
+	return BitSet(s.words).MarshalText()
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (s *Set) UnmarshalText(text []byte) error {
+	return s.bits().UnmarshalText(text)
+}
+
+// FormatList returns the set in the list format of the Linux kernel used by
+// cpusets and sysfs files.
+func (s Set) FormatList() string {
+	return BitSet(s.words).FormatList()
+}
+
+// Freeze returns an immutable copy of s with trimmed and clipped storage.
+func (s Set) Freeze() Frozen {
+	return BitSet(s.words).Freeze()
+}
+
+// Iterator returns an iterator positioned before the first element of s.
+func (s Set) Iterator() Iterator {
+	return BitSet(s.words).Iterator()
+}
+
+// Random returns an element of s chosen uniformly at random using r, or -1
+// if s is empty.
+func (s Set) Random(r *rand.Rand) int {
+	return BitSet(s.words).Random(r)
+}
+
+// Sample creates a new set of min(k, Size()) elements of s chosen uniformly
+// at random using r; every subset of that size is equally likely.
+func (s Set) Sample(r *rand.Rand, k int) Set {
+	return Set{BitSet(s.words).Sample(r, k)}
+}
+
+// ToRoaringBytes returns the set in the Roaring portable serialization
+// format, which is understood by the Roaring bitmap libraries of many
+// languages.
+func (s Set) ToRoaringBytes() ([]byte, error) {
+	return BitSet(s.words).ToRoaringBytes()
+}
+
+// Value implements the driver.Valuer interface.
+func (s Set) Value() (driver.Value, error) {
+	return BitSet(s.words).Value()
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *Set) Scan(src any) error {
+	return s.bits().Scan(src)
+}
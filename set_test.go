@@ -0,0 +1,345 @@
+package bitset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	typeBitSet    = reflect.TypeFor[BitSet]()
+	typePtrBitSet = reflect.TypeFor[*BitSet]()
+	typeSet       = reflect.TypeFor[Set]()
+	typePtrSet    = reflect.TypeFor[*Set]()
+)
+
+// setType maps a type in a BitSet method signature to the type expected
+// in the corresponding Set method signature.
+func setType(t reflect.Type) reflect.Type {
+	switch t {
+	case typeBitSet:
+		return typeSet
+	case typePtrBitSet:
+		return typePtrSet
+	case reflect.TypeFor[[]BitSet]():
+		return reflect.TypeFor[[]Set]()
+	}
+	return t
+}
+
+func TestSet_MethodSet(t *testing.T) {
+	for _, recv := range [][2]reflect.Type{
+		{typeBitSet, typeSet},
+		{typePtrBitSet, typePtrSet},
+	} {
+		bt, st := recv[0], recv[1]
+		require.Equal(t, bt.NumMethod(), st.NumMethod(), "%v", st)
+		for i := range bt.NumMethod() {
+			bm := bt.Method(i)
+			sm, ok := st.MethodByName(bm.Name)
+			require.True(t, ok, "%v.%s is missing", st, bm.Name)
+			bf, sf := bm.Type, sm.Type
+			require.Equal(t, bf.NumIn(), sf.NumIn(), bm.Name)
+			require.Equal(t, bf.NumOut(), sf.NumOut(), bm.Name)
+			require.Equal(t, bf.IsVariadic(), sf.IsVariadic(), bm.Name)
+			// Skip the receiver, which is the first parameter.
+			for j := 1; j < bf.NumIn(); j++ {
+				require.Equal(t, setType(bf.In(j)), sf.In(j), "%s arg %d", bm.Name, j)
+			}
+			for j := range bf.NumOut() {
+				require.Equal(t, setType(bf.Out(j)), sf.Out(j), "%s result %d", bm.Name, j)
+			}
+		}
+	}
+}
+
+// mirrorSide holds the receiver and the side effects of a method call
+// on one of the two types compared by TestSet_Mirror.
+type mirrorSide struct {
+	set bool          // the Set side
+	log []int         // arguments passed to callbacks
+	dst []*BitSet     // *BitSet arguments, or the words of *Set arguments
+	out *bytes.Buffer // io.Writer argument
+}
+
+// mirrorArg is a candidate argument of a BitSet method, created for each
+// side separately, so that the side effects stay apart.
+type mirrorArg func(side *mirrorSide) any
+
+// of returns bs as a Set on the Set side.
+func (side *mirrorSide) of(bs BitSet) any {
+	if side.set {
+		return Set{bs}
+	}
+	return bs
+}
+
+// mirrorArgs returns the candidate arguments of the given type for the
+// BitSet method name; nil means the method is not mirrored automatically.
+func mirrorArgs(name string, t reflect.Type, others []BitSet) []mirrorArg {
+	val := func(v any) mirrorArg { return func(*mirrorSide) any { return v } }
+	switch t {
+	case reflect.TypeFor[int]():
+		return []mirrorArg{val(-1), val(0), val(3), val(64), val(130)}
+	case reflect.TypeFor[float64]():
+		return []mirrorArg{val(-0.1), val(0.0), val(0.5), val(1.0)}
+	case reflect.TypeFor[bool]():
+		return []mirrorArg{val(false), val(true)}
+	case reflect.TypeFor[[]int]():
+		return []mirrorArg{val([]int(nil)), val([]int{5, 1, 64, -2, 200})}
+	case typeBitSet:
+		var args []mirrorArg
+		for _, o := range others {
+			args = append(args, func(side *mirrorSide) any { return side.of(o.Copy()) })
+		}
+		return args
+	case reflect.TypeFor[[]BitSet]():
+		return []mirrorArg{func(side *mirrorSide) any {
+			if side.set {
+				return []Set(nil)
+			}
+			return []BitSet(nil)
+		}, func(side *mirrorSide) any {
+			if side.set {
+				return []Set{{others[0].Copy()}, {others[1].Copy()}}
+			}
+			return []BitSet{others[0].Copy(), others[1].Copy()}
+		}}
+	case typePtrBitSet:
+		return []mirrorArg{func(side *mirrorSide) any {
+			dst := &Set{New(1000)}
+			side.dst = append(side.dst, dst.bits())
+			if side.set {
+				return dst
+			}
+			return dst.bits()
+		}}
+	case reflect.TypeFor[func(int) bool]():
+		return []mirrorArg{func(side *mirrorSide) any {
+			return func(n int) bool {
+				side.log = append(side.log, n)
+				return n%3 == 0
+			}
+		}}
+	case reflect.TypeFor[func(int) int]():
+		return []mirrorArg{func(side *mirrorSide) any {
+			return func(n int) int {
+				side.log = append(side.log, n)
+				return 2*n - 5
+			}
+		}}
+	case reflect.TypeFor[*rand.Rand]():
+		// A nil *rand.Rand would make the two calls disagree.
+		return []mirrorArg{func(*mirrorSide) any {
+			return rand.New(rand.NewPCG(1, 2))
+		}}
+	case reflect.TypeFor[io.Writer]():
+		return []mirrorArg{func(side *mirrorSide) any {
+			side.out = new(bytes.Buffer)
+			return side.out
+		}}
+	case reflect.TypeFor[io.Reader]():
+		var w bytes.Buffer
+		_, _ = others[0].WriteTo(&w)
+		return []mirrorArg{func(*mirrorSide) any { return bytes.NewReader(w.Bytes()) }}
+	case reflect.TypeFor[any]():
+		data, _ := others[0].MarshalBinary()
+		return []mirrorArg{val(nil), val(data), val(42)}
+	case reflect.TypeFor[[]byte]():
+		var data []byte
+		switch name {
+		case "UnmarshalBinary":
+			data, _ = others[0].MarshalBinary()
+		case "GobDecode":
+			data, _ = others[0].GobEncode()
+		case "UnmarshalJSON":
+			data, _ = others[0].MarshalJSON()
+		case "UnmarshalText":
+			data, _ = others[0].MarshalText()
+		}
+		return []mirrorArg{val([]byte("x")), val(data)}
+	case reflect.TypeFor[func(int)]():
+		return []mirrorArg{func(side *mirrorSide) any {
+			return func(n int) { side.log = append(side.log, n) }
+		}}
+	}
+	return nil
+}
+
+// mirrorValue converts a result of a BitSet or Set method to a form that
+// compares equal for both.
+func mirrorValue(v any) any {
+	words := func(w []uint64) any {
+		if len(w) == 0 {
+			return []uint64(nil)
+		}
+		return w
+	}
+	switch v := v.(type) {
+	case BitSet:
+		return words(v)
+	case Set:
+		return words(v.words)
+	case iter.Seq[int]:
+		var elems []int
+		for n := range v {
+			elems = append(elems, n)
+		}
+		return elems
+	case iter.Seq2[int, int]:
+		var pairs [][2]int
+		for a, b := range v {
+			pairs = append(pairs, [2]int{a, b})
+		}
+		return pairs
+	}
+	return v
+}
+
+// mirrorCall calls method with args and returns the normalized results,
+// or the value of the panic.
+func mirrorCall(method reflect.Value, args []any) (res []any) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = []any{fmt.Sprint("panic: ", r)}
+		}
+	}()
+	in := make([]reflect.Value, len(args))
+	for i, a := range args {
+		if a == nil {
+			in[i] = reflect.Zero(method.Type().In(i))
+			continue
+		}
+		in[i] = reflect.ValueOf(a)
+	}
+	var out []reflect.Value
+	if method.Type().IsVariadic() {
+		out = method.CallSlice(in)
+	} else {
+		out = method.Call(in)
+	}
+	for _, o := range out {
+		res = append(res, mirrorValue(o.Interface()))
+	}
+	return res
+}
+
+// TestSet_Mirror calls every method of BitSet and the corresponding method
+// of Set with the same receivers and arguments, and checks that the results,
+// the receivers and the side effects are the same.
+func TestSet_Mirror(t *testing.T) {
+	fixtures := []BitSet{
+		New(),
+		New(0),
+		New(1, 2, 3, 64, 65, 200),
+		FromRange(10, 150),
+		New(63, 64, 127, 128),
+		append(New(5), 0, 0),
+	}
+	others := []BitSet{New(2, 64, 65), FromRange(0, 70), New()}
+	skip := map[string]bool{
+		"Format":   true, // checked by TestSet_Conversion
+		"GoString": true, // wraps the BitSet source in ToSet
+	}
+
+	bt := typePtrBitSet
+	calls := 0
+	for i := range bt.NumMethod() {
+		m := bt.Method(i)
+		if skip[m.Name] {
+			continue
+		}
+		var cands [][]mirrorArg
+		for j := 1; j < m.Type.NumIn(); j++ {
+			args := mirrorArgs(m.Name, m.Type.In(j), others)
+			require.NotEmpty(t, args, "%s: no arguments of type %v", m.Name, m.Type.In(j))
+			cands = append(cands, args)
+		}
+		for _, f := range fixtures {
+			for _, choice := range product(cands) {
+				bside, sside := mirrorSide{}, mirrorSide{set: true}
+				bargs := make([]any, len(choice))
+				sargs := make([]any, len(choice))
+				for j, c := range choice {
+					bargs[j] = c(&bside)
+					sargs[j] = c(&sside)
+				}
+				bs := append(BitSet(nil), f...)
+				s := Set{append(BitSet(nil), f...)}
+				bres := mirrorCall(reflect.ValueOf(&bs).MethodByName(m.Name), bargs)
+				sres := mirrorCall(reflect.ValueOf(&s).MethodByName(m.Name), sargs)
+				msg := fmt.Sprintf("%s on %v with %v", m.Name, f, bargs)
+				require.Equal(t, bres, sres, msg)
+				require.Equal(t, mirrorValue(bs), mirrorValue(s), msg)
+				require.Equal(t, bside.log, sside.log, msg)
+				require.Equal(t, len(bside.dst), len(sside.dst), msg)
+				for j := range bside.dst {
+					require.Equal(t, mirrorValue(*bside.dst[j]), mirrorValue(*sside.dst[j]), msg)
+				}
+				if bside.out != nil {
+					require.Equal(t, bside.out.Bytes(), sside.out.Bytes(), msg)
+				}
+				calls++
+			}
+		}
+	}
+	require.Greater(t, calls, 1000)
+}
+
+// product returns all combinations that pick one element of each of lists.
+func product[T any](lists [][]T) [][]T {
+	res := [][]T{nil}
+	for _, l := range lists {
+		var next [][]T
+		for _, r := range res {
+			for _, e := range l {
+				next = append(next, append(r[:len(r):len(r)], e))
+			}
+		}
+		res = next
+	}
+	return res
+}
+
+func TestSet_Conversion(t *testing.T) {
+	for _, bs := range []BitSet{
+		nil,
+		New(),
+		New(0),
+		New(1, 2, 3, 64, 65, 200),
+		FromRange(10, 1000),
+		append(New(5), 0, 0),
+	} {
+		s := ToSet(bs)
+		require.NoError(t, s.Validate())
+		require.Equal(t, bs.ToSlice(), s.ToSlice())
+
+		back := AsBitSet(s)
+		require.True(t, back.Equal(bs))
+		require.NoError(t, back.Validate())
+		require.True(t, ToSet(back).Equal(s))
+
+		// The conversions copy, so the sets don't share storage.
+		s.Add(7)
+		require.Equal(t, bs.Contains(7), back.Contains(7))
+		back.Add(9)
+		require.Equal(t, bs.Contains(9), s.Contains(9))
+
+		require.Equal(t, fmt.Sprintf("%v %d %s", bs, bs, bs),
+			fmt.Sprintf("%v %d %s", ToSet(bs), ToSet(bs), ToSet(bs)))
+	}
+
+	var zero Set
+	require.True(t, zero.Empty())
+	require.Equal(t, "{}", zero.String())
+	require.Equal(t, New(), AsBitSet(zero))
+	zero.Add(3)
+	require.True(t, zero.Equal(NewSet(3)))
+	require.Equal(t, "bitset.ToSet(bitset.New(1, 2, 128))", NewSet(1, 2, 128).GoString())
+}
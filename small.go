@@ -0,0 +1,178 @@
+package bitset
+
+import "math/bits"
+
+// Small is a set of non-negative integers optimized for sets that rarely
+// hold elements greater than 63. The elements from 0 to 63 are stored
+// inline, so such sets need no heap allocation; greater elements spill
+// to a BitSet, which is emptied again when they are deleted, keeping its
+// capacity for later spills. The zero value of Small is an empty set.
+type Small struct {
+	lo uint64 // elements from 0 to 63
+	hi BitSet // elements from 64 on, decreased by 64
+}
+
+// NewSmall creates a new small set with the given non-negative elements.
+// It panics if an element is greater than MaxElement.
+func NewSmall(n ...int) Small {
+	var s Small
+	s.Add(n...)
+	return s
+}
+
+// SmallFrom creates a new small set with the elements of bs.
+// Later changes to bs don't affect the returned set.
+func SmallFrom(bs BitSet) Small {
+	s := Small{lo: bs.Word(0)}
+	if n := bs.trimmedLen(); n > 1 {
+		s.hi = bs[1:n].Copy()
+	}
+	return s
+}
+
+// Add adds the given elements to s, skipping negative ones.
+// It panics if an element is greater than MaxElement.
+func (s *Small) Add(n ...int) {
+	for _, e := range n {
+		switch {
+		case e < 0:
+		case e < bpw:
+			s.lo |= 1 << uint(e)
+		default:
+			mustFit(e)
+			s.hi.Add(e - bpw)
+		}
+	}
+}
+
+// Delete removes the given elements from s.
+func (s *Small) Delete(n ...int) {
+	for _, e := range n {
+		switch {
+		case e < 0:
+		case e < bpw:
+			s.lo &^= 1 << uint(e)
+		default:
+			s.hi.Delete(e - bpw)
+		}
+	}
+}
+
+// AddRange adds all integers from m to n-1 to s (no-op if m>=n).
+func (s *Small) AddRange(m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	m = max(0, m)
+	mustFit(n - 1)
+	if m < bpw {
+		s.lo |= bitMask(m, min(n, bpw)-1)
+	}
+	if n > bpw {
+		s.hi.AddRange(max(m, bpw)-bpw, n-bpw)
+	}
+}
+
+// DeleteRange removes all integers from m to n-1 from s (no-op if m>=n).
+func (s *Small) DeleteRange(m, n int) {
+	if n < 1 || m >= n {
+		return
+	}
+	m = max(0, m)
+	if m < bpw {
+		s.lo &^= bitMask(m, min(n, bpw)-1)
+	}
+	if n > bpw {
+		s.hi.DeleteRange(max(m, bpw)-bpw, n-bpw)
+	}
+}
+
+// Reset removes all elements from s, keeping the spilled storage.
+func (s *Small) Reset() {
+	s.lo = 0
+	s.hi.Reset()
+}
+
+// Contains tells if n is in s.
+func (s Small) Contains(n int) bool {
+	if n < bpw {
+		return n >= 0 && s.lo&(1<<uint(n)) != 0
+	}
+	return s.hi.Contains(n - bpw)
+}
+
+// Size returns the number of elements in s.
+func (s Small) Size() int {
+	if len(s.hi) == 0 {
+		return bits.OnesCount64(s.lo)
+	}
+	return bits.OnesCount64(s.lo) + s.hi.Size()
+}
+
+// Empty tells if s is empty.
+func (s Small) Empty() bool {
+	return s.lo == 0 && len(s.hi) == 0
+}
+
+// Spilled tells if s holds elements greater than 63,
+// which are stored outside of s.
+func (s Small) Spilled() bool {
+	return len(s.hi) > 0
+}
+
+// Min returns the minimum element of s, or -1 if s is empty.
+func (s Small) Min() int {
+	if s.lo != 0 {
+		return bits.TrailingZeros64(s.lo)
+	}
+	if len(s.hi) == 0 {
+		return -1
+	}
+	return s.hi.Min() + bpw
+}
+
+// Max returns the maximum element of s, or -1 if s is empty.
+func (s Small) Max() int {
+	if len(s.hi) > 0 {
+		return s.hi.Max() + bpw
+	}
+	if s.lo == 0 {
+		return -1
+	}
+	return bpw - 1 - bits.LeadingZeros64(s.lo)
+}
+
+// Equal tells if s and other contain the same elements.
+func (s Small) Equal(other Small) bool {
+	return s.lo == other.lo && s.hi.Equal(other.hi)
+}
+
+// Visit calls the do function for each element of s in numerical order
+// with the same abort semantics as BitSet.Visit.
+func (s Small) Visit(do func(n int) bool) (aborted bool) {
+	for w := s.lo; w != 0; w &= w - 1 {
+		if do(bits.TrailingZeros64(w)) {
+			return true
+		}
+	}
+	if len(s.hi) == 0 {
+		return false
+	}
+	return s.hi.Visit(func(n int) bool {
+		return do(n + bpw)
+	})
+}
+
+// BitSet creates a new BitSet with the elements of s.
+func (s Small) BitSet() BitSet {
+	bs := make(BitSet, 1+len(s.hi))
+	bs[0] = s.lo
+	copy(bs[1:], s.hi)
+	bs.trim()
+	return bs
+}
+
+// String returns a string representation of s like BitSet.String.
+func (s Small) String() string {
+	return s.BitSet().String()
+}
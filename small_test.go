@@ -0,0 +1,130 @@
+package bitset
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmall(t *testing.T) {
+	s := NewSmall(1, 1, -5, 63)
+	require.Equal(t, 2, s.Size())
+	require.Equal(t, "{1 63}", s.String())
+	require.False(t, s.Spilled())
+	require.Equal(t, 1, s.Min())
+	require.Equal(t, 63, s.Max())
+
+	// Spill with the first element that doesn't fit in the inline word.
+	s.Add(64)
+	require.True(t, s.Spilled())
+	require.True(t, s.Contains(63))
+	require.True(t, s.Contains(64))
+	require.False(t, s.Contains(65))
+	require.Equal(t, 64, s.Max())
+	s.Add(65)
+	require.Equal(t, "{1 63..65}", s.String())
+	require.Equal(t, 4, s.Size())
+
+	// Un-spill once the last element greater than 63 is gone.
+	s.Delete(64)
+	require.True(t, s.Spilled())
+	s.Delete(65)
+	require.False(t, s.Spilled())
+	require.Equal(t, 63, s.Max())
+	require.Equal(t, "{1 63}", s.String())
+
+	s.AddRange(60, 66)
+	require.True(t, s.Spilled())
+	require.Equal(t, "{1 60..65}", s.String())
+	s.DeleteRange(64, 1000)
+	require.False(t, s.Spilled())
+	require.Equal(t, "{1 60..63}", s.String())
+	s.AddRange(63, 65)
+	s.DeleteRange(63, 65)
+	require.False(t, s.Spilled())
+	require.Equal(t, "{1 60..62}", s.String())
+
+	// Only elements above 63 spill.
+	s.DeleteRange(0, 64)
+	require.True(t, s.Empty())
+	require.Equal(t, -1, s.Min())
+	require.Equal(t, -1, s.Max())
+	s.Add(200)
+	require.Equal(t, 200, s.Min())
+	require.True(t, s.Equal(SmallFrom(New(200))))
+	s.Reset()
+	require.True(t, s.Empty())
+	require.True(t, s.Equal(Small{}))
+
+	require.False(t, s.Contains(-1))
+	require.False(t, NewSmall(0).Contains(-64))
+
+	maxElement = 1000
+	t.Cleanup(func() { maxElement = MaxElement })
+	msg := "bitset: element 1001 exceeds the maximum of 1000"
+	require.PanicsWithError(t, msg, func() { s.Add(1001) })
+	require.PanicsWithError(t, msg, func() { s.AddRange(0, 1002) })
+	s.AddRange(0, 1001)
+	require.Equal(t, 1001, s.Size())
+}
+
+func TestSmall_Equivalence(t *testing.T) {
+	r := rand.New(rand.NewPCG(13, 14))
+	for range 50 {
+		var s Small
+		var bs BitSet
+		for range 100 {
+			// Concentrate the elements around the inline word boundary.
+			m := r.IntN(200) - 20
+			n := m + r.IntN(100) - 10
+			switch r.IntN(4) {
+			case 0:
+				s.Add(m, n)
+				bs.Add(m, n)
+			case 1:
+				s.Delete(m, n, bs.Max())
+				bs.Delete(m, n, bs.Max())
+			case 2:
+				s.AddRange(m, n)
+				bs.AddRange(m, n)
+			case 3:
+				s.DeleteRange(m, n)
+				bs.DeleteRange(m, n)
+			}
+			require.Equal(t, bs.Size(), s.Size())
+			require.Equal(t, bs.Min(), s.Min())
+			require.Equal(t, bs.Max(), s.Max())
+			require.Equal(t, bs.Empty(), s.Empty())
+			require.Equal(t, bs.Max() >= bpw, s.Spilled())
+			require.True(t, bs.Equal(s.BitSet()))
+			require.True(t, s.Equal(SmallFrom(bs)))
+			require.Equal(t, bs.Contains(m), s.Contains(m))
+			require.Equal(t, bs.ToSlice(), visitSmall(s))
+			require.NoError(t, s.hi.Validate())
+		}
+	}
+}
+
+// visitSmall returns the elements of s collected by Visit.
+func visitSmall(s Small) []int {
+	elems := []int{}
+	s.Visit(func(n int) bool {
+		elems = append(elems, n)
+		return false
+	})
+	return elems
+}
+
+func TestSmall_NoAlloc(t *testing.T) {
+	require.Zero(t, testing.AllocsPerRun(100, func() {
+		s := NewSmall(0, 5, 63)
+		s.AddRange(10, 20)
+		s.Delete(5)
+		s.DeleteRange(12, 60)
+		if !s.Contains(63) || s.Size() != 4 {
+			panic("unexpected set")
+		}
+		s.Visit(func(n int) bool { return n > 10 })
+	}))
+}
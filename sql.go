@@ -0,0 +1,29 @@
+package bitset
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value implements the driver.Valuer interface.
+// The set is stored as the []byte encoding produced by MarshalBinary.
+func (bs BitSet) Value() (driver.Value, error) {
+	return bs.MarshalBinary()
+}
+
+// Scan implements the sql.Scanner interface. It accepts the encoding
+// produced by Value as []byte or string, and nil as the empty set.
+// The source buffer is not retained.
+func (bs *BitSet) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		bs.Reset()
+		return nil
+	case []byte:
+		return bs.UnmarshalBinary(src)
+	case string:
+		return bs.UnmarshalBinary([]byte(src))
+	default:
+		return fmt.Errorf("bitset: cannot scan %T into BitSet", src)
+	}
+}
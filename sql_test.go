@@ -0,0 +1,140 @@
+package bitset
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitSet_Scan(t *testing.T) {
+	data, err := New(1, 100).MarshalBinary()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		src    any
+		expect string
+		err    string
+	}{
+		{"nil", nil, "{}", ""},
+		{"bytes", data, "{1 100}", ""},
+		{"string", string(data), "{1 100}", ""},
+		{"malformed length", data[:len(data)-1], "", "bitset: binary data holds 15 bytes, expected 2 words"},
+		{"empty bytes", []byte{}, "", "bitset: missing binary version"},
+		{"unsupported type", 42, "", "bitset: cannot scan int into BitSet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := New(5, 6)
+			err := bs.Scan(tt.src)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, bs.String())
+		})
+	}
+
+	t.Run("buffer not retained", func(t *testing.T) {
+		buf := append([]byte(nil), data...)
+		var bs BitSet
+		require.NoError(t, bs.Scan(buf))
+		clear(buf)
+		require.Equal(t, "{1 100}", bs.String())
+	})
+}
+
+func TestBitSet_SQL(t *testing.T) {
+	db, err := sql.Open("bitset-fake", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	type row struct {
+		ID      int
+		Members BitSet
+	}
+	for _, in := range []row{
+		{1, New(1, 2, 300)},
+		{2, New()},
+		{3, nil},
+	} {
+		_, err = db.Exec("INSERT", in.ID, in.Members)
+		require.NoError(t, err)
+
+		var out row
+		out.Members = New(7) // must be replaced
+		err = db.QueryRow("SELECT", in.ID).Scan(&out.ID, &out.Members)
+		require.NoError(t, err)
+		require.Equal(t, in.ID, out.ID)
+		require.True(t, out.Members.Equal(in.Members), out.Members.String())
+	}
+}
+
+func init() {
+	sql.Register("bitset-fake", &fakeDriver{rows: map[int64][]byte{}})
+}
+
+// fakeDriver is a database/sql driver storing one bytea column per integer
+// key. Any Exec stores the value given as the second argument under the key
+// given as the first, and any Query returns the key and its value.
+type fakeDriver struct {
+	mu   sync.Mutex
+	rows map[int64][]byte
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return &fakeStmt{c.d}, nil }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	b, ok := args[1].([]byte)
+	if !ok {
+		return nil, errors.New("value is not []byte")
+	}
+	// Keep a copy, like a real database, and hand out a scratch buffer later.
+	s.d.rows[args[0].(int64)] = append([]byte(nil), b...)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	key := args[0].(int64)
+	return &fakeRows{key: key, value: append([]byte(nil), s.d.rows[key]...)}, nil
+}
+
+type fakeRows struct {
+	key   int64
+	value []byte
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id", "members"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0], dest[1] = r.key, r.value
+	return nil
+}